@@ -0,0 +1,673 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+// browser fills the filter options and filter output panes of the main window
+// when a filter button is pressed.
+type browser struct {
+	ctx     context.Context //nolint:containedctx
+	dbase   database.Database
+	window  fyne.Window
+	options *fyne.Container
+	output  *fyne.Container
+}
+
+func newBrowser(ctx context.Context, dbase database.Database, window fyne.Window) *browser {
+	return &browser{
+		ctx:     ctx,
+		dbase:   dbase,
+		window:  window,
+		options: container.NewVBox(),
+		output:  container.NewStack(),
+	}
+}
+
+// showOptions replaces the contents of the filter options pane.
+func (b *browser) showOptions(objects ...fyne.CanvasObject) {
+	b.options.Objects = objects
+	b.options.Refresh()
+}
+
+// showOutput replaces the contents of the filter output pane.
+func (b *browser) showOutput(object fyne.CanvasObject) {
+	b.output.Objects = []fyne.CanvasObject{object}
+	b.output.Refresh()
+}
+
+func (b *browser) showError(err error) {
+	mainLog.Println(err)
+	dialog.ShowError(err, b.window)
+}
+
+// titleFilter shows the series whose titles contain the text entered, updating
+// as it is typed.
+func (b *browser) titleFilter() {
+	mainLog.Println("showing title filter")
+
+	count := widget.NewLabel("")
+
+	includeRemoved := widget.NewCheck("Include removed from DCUI", nil)
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Series title")
+	entry.OnChanged = func(title string) {
+		series, err := b.dbase.SearchSeries(b.ctx, title, includeRemoved.Checked)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		opts := database.QueryOptions{IncludeRemoved: includeRemoved.Checked}
+
+		count.SetText(fmt.Sprintf("%v series", len(series)))
+		b.showOutput(container.NewBorder(nil,
+			container.NewHBox(b.exportButton(database.ByTitle(title), false, opts)),
+			nil, nil, b.seriesTable(series)))
+	}
+
+	includeRemoved.OnChanged = func(bool) {
+		entry.OnChanged(entry.Text)
+	}
+
+	b.showOptions(widget.NewLabel("Title contains:"), entry, includeRemoved, count)
+	entry.OnChanged("")
+	b.window.Canvas().Focus(entry)
+}
+
+// seriesColumns are the headings of the columns in seriesTable.
+var seriesColumns = []string{"Title", "Issues", "Volumes", "Omnibuses", "URL"} //nolint:gochecknoglobals
+
+// seriesTable lists series with their book counts. Selecting a URL opens it
+// in the browser, and selecting anything else shows the series' details.
+func (b *browser) seriesTable(series []database.Series) *widget.Table {
+	table := widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(series), len(seriesColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, cell fyne.CanvasObject) {
+			s := series[id.Row]
+
+			var text string
+
+			switch id.Col {
+			case 0:
+				text = seriesTitle(s)
+			case 1:
+				text = strconv.Itoa(s.IssueCount)
+			case 2:
+				text = strconv.Itoa(s.VolumeCount)
+			case 3:
+				text = strconv.Itoa(s.OmnibusCount)
+			case 4:
+				text = s.URL
+			}
+
+			label := cell.(*widget.Label) //nolint:forcetypeassert
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.SetText(text)
+		})
+
+	table.ShowHeaderColumn = false
+	table.UpdateHeader = func(id widget.TableCellID, cell fyne.CanvasObject) {
+		cell.(*widget.Label).SetText(seriesColumns[id.Col]) //nolint:forcetypeassert
+	}
+
+	table.SetColumnWidth(0, 320)
+	table.SetColumnWidth(1, 70)
+	table.SetColumnWidth(2, 70)
+	table.SetColumnWidth(3, 90)
+	table.SetColumnWidth(4, 420)
+
+	table.OnSelected = func(id widget.TableCellID) {
+		if id.Col == 4 {
+			openURL(series[id.Row].URL)
+		} else {
+			b.seriesDetails(series[id.Row])
+		}
+
+		table.Unselect(id)
+	}
+
+	return table
+}
+
+func openURL(rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		mainLog.Println(err)
+
+		return
+	}
+
+	err = fyne.CurrentApp().OpenURL(u)
+	if err != nil {
+		mainLog.Println(err)
+	}
+}
+
+// dateFilter shows the issues published on DCUI between the dates entered,
+// inclusive. The quick range buttons fill in the dates ending today.
+func (b *browser) dateFilter() {
+	mainLog.Println("showing date filter")
+
+	count := widget.NewLabel("")
+
+	from := newDateEntry()
+	to := newDateEntry()
+	includeRemoved := widget.NewCheck("Include removed from DCUI", nil)
+
+	show := func() {
+		start, end, err := dateRange(from.Text, to.Text)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		issues, err := b.dbase.IssuesPublished(b.ctx, start, end, includeRemoved.Checked)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		opts := database.QueryOptions{IncludeRemoved: includeRemoved.Checked}
+
+		count.SetText(fmt.Sprintf("%v issues", len(issues)))
+		b.showOutput(container.NewBorder(nil,
+			container.NewHBox(b.exportButton(database.PublishedBetween(start, end), true, opts)),
+			nil, nil, b.issueTable(issues)))
+	}
+
+	showLastDays := func(days int) {
+		today := time.Now()
+		from.SetText(today.AddDate(0, 0, -days).Format(time.DateOnly))
+		to.SetText(today.Format(time.DateOnly))
+		show()
+	}
+
+	lastDays := func(label string, days int) *widget.Button {
+		return widget.NewButton(label, func() { showLastDays(days) })
+	}
+
+	from.OnSubmitted = func(string) { show() }
+	to.OnSubmitted = func(string) { show() }
+
+	b.showOptions(
+		widget.NewLabel("Published from:"), from,
+		widget.NewLabel("Published to:"), to,
+		includeRemoved,
+		widget.NewButton("Show Issues", show),
+		widget.NewSeparator(),
+		lastDays("Last 7 Days", 7), //nolint:mnd
+		lastDays("Last Month", 30), //nolint:mnd
+		lastDays("Last Year", 365), //nolint:mnd
+		count)
+	showLastDays(30) //nolint:mnd
+}
+
+// newDateEntry returns an entry for a YYYY-MM-DD date, which may be left
+// empty.
+func newDateEntry() *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("YYYY-MM-DD")
+	entry.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+
+		_, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return errors.New("not a YYYY-MM-DD date")
+		}
+
+		return nil
+	}
+
+	return entry
+}
+
+// issueColumns are the headings of the columns in issueTable.
+var issueColumns = []string{"Published", "Series", "Issue", "Title", "Plans", "URL"} //nolint:gochecknoglobals
+
+// issueTable lists issues with their series. Selecting a URL opens it in the
+// browser, and selecting anything else shows what has been recorded about
+// reading the issue.
+func (b *browser) issueTable(issues []database.Issue) *widget.Table {
+	table := widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(issues), len(issueColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, cell fyne.CanvasObject) {
+			i := issues[id.Row]
+
+			var text string
+
+			switch id.Col {
+			case 0:
+				text = publishedDate(i)
+			case 1:
+				text = seriesTitle(i.Series)
+			case 2:
+				text = i.IssueNumber
+			case 3:
+				text = issueTitle(i)
+			case 4:
+				text = planNames(i.Plans)
+			case 5:
+				text = i.URL
+			}
+
+			label := cell.(*widget.Label) //nolint:forcetypeassert
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.SetText(text)
+		})
+
+	table.ShowHeaderColumn = false
+	table.UpdateHeader = func(id widget.TableCellID, cell fyne.CanvasObject) {
+		cell.(*widget.Label).SetText(issueColumns[id.Col]) //nolint:forcetypeassert
+	}
+
+	table.SetColumnWidth(0, 100)
+	table.SetColumnWidth(1, 260)
+	table.SetColumnWidth(2, 60)
+	table.SetColumnWidth(3, 300)
+	table.SetColumnWidth(4, 80)
+	table.SetColumnWidth(5, 420)
+
+	table.OnSelected = func(id widget.TableCellID) {
+		if id.Col == 5 {
+			openURL(issues[id.Row].URL)
+		} else {
+			b.issueDetails(issues[id.Row])
+		}
+
+		table.Unselect(id)
+	}
+
+	return table
+}
+
+// searchLimit is the most hits shown for a full-text search.
+const searchLimit = 500
+
+// textSearch shows the series and issues whose titles or descriptions
+// contain the words entered, best match first, updating as they are typed.
+func (b *browser) textSearch() {
+	mainLog.Println("showing full-text search")
+
+	count := widget.NewLabel("")
+
+	includeRemoved := widget.NewCheck("Include removed from DCUI", nil)
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Words to find")
+	entry.OnChanged = func(query string) {
+		hits, err := b.dbase.Search(b.ctx, query, searchLimit, includeRemoved.Checked)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		count.SetText(fmt.Sprintf("%v matches", len(hits)))
+		b.showOutput(searchList(hits))
+	}
+
+	includeRemoved.OnChanged = func(bool) {
+		entry.OnChanged(entry.Text)
+	}
+
+	options := []fyne.CanvasObject{widget.NewLabel("Titles or descriptions containing:"), entry, includeRemoved, count}
+
+	if !b.dbase.FullTextSearch() {
+		fallback := widget.NewLabel("Basic search: this build has no full-text index (FTS5), so matches are " +
+			"ranked less well. Build with make to include it.")
+		fallback.Wrapping = fyne.TextWrapWord
+		options = append(options, fallback)
+	}
+
+	b.showOptions(options...)
+	entry.OnChanged("")
+	b.window.Canvas().Focus(entry)
+}
+
+// searchList lists search hits with their snippets, matches in bold.
+// Selecting a hit opens it in the browser.
+func searchList(hits []database.SearchHit) *widget.List {
+	list := widget.NewList(
+		func() int {
+			return len(hits)
+		},
+		func() fyne.CanvasObject {
+			title := widget.NewLabel("")
+			title.TextStyle.Bold = true
+			title.Truncation = fyne.TextTruncateEllipsis
+
+			snippet := widget.NewRichText()
+			snippet.Truncation = fyne.TextTruncateEllipsis
+
+			return container.NewVBox(title, snippet)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			h := hits[id]
+			objects := item.(*fyne.Container).Objects //nolint:forcetypeassert
+
+			title := hitTitle(h)
+			if h.Kind == database.KindIssue {
+				title += " (issue of " + h.SeriesTitle + ")"
+			}
+
+			objects[0].(*widget.Label).SetText(title) //nolint:forcetypeassert
+
+			segments := make([]widget.RichTextSegment, len(h.Snippet))
+			for i, p := range h.Snippet {
+				style := widget.RichTextStyleInline
+				if p.Match {
+					style = widget.RichTextStyleStrong
+				}
+
+				segments[i] = &widget.TextSegment{Text: p.Text, Style: style}
+			}
+
+			snippet := objects[1].(*widget.RichText) //nolint:forcetypeassert
+			snippet.Segments = segments
+			snippet.Refresh()
+		})
+
+	list.OnSelected = func(id widget.ListItemID) {
+		openURL(hits[id].URL)
+		list.UnselectAll()
+	}
+
+	return list
+}
+
+// filterPageSize is how many series or issues attributeFilter shows at once.
+const filterPageSize = 200
+
+// attributeFilter shows the series, or issues, matching the genres, imprints,
+// creators, tags, title, issue counts and publication dates chosen.
+func (b *browser) attributeFilter() {
+	mainLog.Println("showing attribute filter")
+
+	genres, err := b.dbase.Genres(b.ctx)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	imprints, err := b.dbase.Imprints(b.ctx)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	genreChecks := widget.NewCheckGroup(genres, nil)
+	imprintChecks := widget.NewCheckGroup(imprints, nil)
+
+	creators := widget.NewEntry()
+	creators.SetPlaceHolder("Comma separated")
+
+	tags := widget.NewEntry()
+	tags.SetPlaceHolder("Comma separated")
+
+	title := widget.NewEntry()
+
+	minIssues := newCountEntry()
+	maxIssues := newCountEntry()
+	from := newDateEntry()
+	to := newDateEntry()
+
+	match := widget.NewRadioGroup([]string{"All", "Any"}, nil)
+	match.Horizontal = true
+	match.SetSelected("All")
+
+	show := widget.NewRadioGroup([]string{"Series", "Issues"}, nil)
+	show.Horizontal = true
+	show.SetSelected("Series")
+
+	plans := widget.NewEntry()
+	plans.SetPlaceHolder("Comma separated, such as ultra")
+
+	movedToBase := widget.NewCheck("Moved to the base tier this month", nil)
+	scheduled := widget.NewCheck("Scheduled to be added", nil)
+
+	includeRemoved := widget.NewCheck("Include removed from DCUI", nil)
+
+	count := widget.NewLabel("")
+
+	apply := func() {
+		spec := newFilterSpec()
+		spec.genres = genreChecks.Selected
+		spec.imprints = imprintChecks.Selected
+		spec.creators = splitList(creators.Text)
+		spec.tags = splitList(tags.Text)
+		spec.title = title.Text
+		spec.from = from.Text
+		spec.to = to.Text
+		spec.plans = splitList(plans.Text)
+		spec.scheduled = scheduled.Checked
+		spec.matchAny = match.Selected == "Any"
+
+		if movedToBase.Checked {
+			spec.movedSince = monthStart(time.Now())
+		}
+
+		var err error
+
+		spec.minIssues, err = parseCount(minIssues.Text)
+		if err != nil {
+			b.showError(fmt.Errorf("minimum issues: %w", err))
+
+			return
+		}
+
+		spec.maxIssues, err = parseCount(maxIssues.Text)
+		if err != nil {
+			b.showError(fmt.Errorf("maximum issues: %w", err))
+
+			return
+		}
+
+		filter, err := spec.filter()
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		opts := database.QueryOptions{Limit: filterPageSize, IncludeRemoved: includeRemoved.Checked}
+		b.showFilterPage(filter, show.Selected == "Issues", opts, count)
+	}
+
+	b.showOptions(
+		widget.NewAccordion(
+			widget.NewAccordionItem("Genres", genreChecks),
+			widget.NewAccordionItem("Imprints", imprintChecks)),
+		widget.NewForm(
+			widget.NewFormItem("Creators", creators),
+			widget.NewFormItem("Tags", tags),
+			widget.NewFormItem("Title", title),
+			widget.NewFormItem("Min issues", minIssues),
+			widget.NewFormItem("Max issues", maxIssues),
+			widget.NewFormItem("From", from),
+			widget.NewFormItem("To", to),
+			widget.NewFormItem("Plans", plans),
+			widget.NewFormItem("Match", match),
+			widget.NewFormItem("Show", show)),
+		movedToBase,
+		scheduled,
+		includeRemoved,
+		widget.NewButton("Apply Filter", apply),
+		count)
+}
+
+// showFilterPage shows the page of series, or issues, matching filter
+// selected by opts, with buttons to move between pages.
+func (b *browser) showFilterPage(filter database.Filter, issues bool, opts database.QueryOptions,
+	count *widget.Label,
+) {
+	offset := opts.Offset
+
+	var (
+		table        fyne.CanvasObject
+		shown, total int
+		err          error
+	)
+
+	if issues {
+		var results []database.Issue
+
+		results, total, err = b.dbase.QueryIssues(b.ctx, filter, opts)
+		shown = len(results)
+		table = b.issueTable(results)
+	} else {
+		var results []database.Series
+
+		results, total, err = b.dbase.QuerySeries(b.ctx, filter, opts)
+		shown = len(results)
+		table = b.seriesTable(results)
+	}
+
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	if total == 0 {
+		count.SetText("No matches")
+	} else {
+		count.SetText(fmt.Sprintf("%v to %v of %v", offset+1, offset+shown, total))
+	}
+
+	previous := widget.NewButton("Previous", func() {
+		opts.Offset = max(offset-filterPageSize, 0)
+		b.showFilterPage(filter, issues, opts, count)
+	})
+	if offset == 0 {
+		previous.Disable()
+	}
+
+	next := widget.NewButton("Next", func() {
+		opts.Offset = offset + filterPageSize
+		b.showFilterPage(filter, issues, opts, count)
+	})
+	if offset+shown >= total {
+		next.Disable()
+	}
+
+	b.showOutput(container.NewBorder(nil, container.NewHBox(previous, next,
+		b.exportButton(filter, issues, database.QueryOptions{IncludeRemoved: opts.IncludeRemoved})),
+		nil, nil, table))
+}
+
+// newCountEntry returns an entry for a number of issues, which may be left
+// empty.
+func newCountEntry() *widget.Entry {
+	entry := widget.NewEntry()
+	entry.Validator = func(s string) error {
+		_, err := parseCount(s)
+
+		return err
+	}
+
+	return entry
+}
+
+// parseCount parses a number of issues entered by the user, returning -1 if
+// none was.
+func parseCount(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return -1, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, errors.New("not a number of issues")
+	}
+
+	return n, nil
+}
+
+// exportButton returns a button that exports the series, or issues, matching
+// filter and selected by opts to a file chosen by the user, in the format
+// given by its extension.
+func (b *browser) exportButton(filter database.Filter, issues bool, opts database.QueryOptions) *widget.Button {
+	if issues {
+		return b.saveButton("issues.csv", func(w io.Writer, format database.ExportFormat) (int, error) {
+			return b.dbase.ExportIssues(b.ctx, w, format, filter, opts)
+		})
+	}
+
+	return b.saveButton("series.csv", func(w io.Writer, format database.ExportFormat) (int, error) {
+		return b.dbase.ExportSeries(b.ctx, w, format, filter, opts)
+	})
+}
+
+// saveButton returns a button that writes an export to a file chosen by the
+// user, suggesting name, in the format given by its extension.
+func (b *browser) saveButton(name string, write func(io.Writer, database.ExportFormat) (int, error)) *widget.Button {
+	return widget.NewButton("Export...", func() {
+		save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil {
+				b.showError(err)
+
+				return
+			}
+
+			if w == nil {
+				return
+			}
+			defer w.Close()
+
+			format, err := database.ParseExportFormat(w.URI().Extension())
+			if err != nil {
+				b.showError(errors.New("export files must end in .csv, .json or .jsonl"))
+
+				return
+			}
+
+			mainLog.Printf("exporting to %v\n", w.URI())
+
+			n, err := write(w, format)
+			if err != nil {
+				b.showError(err)
+
+				return
+			}
+
+			dialog.ShowInformation("Export Complete", fmt.Sprintf("Exported %v to %v", n, w.URI().Name()),
+				b.window)
+		}, b.window)
+
+		save.SetFileName(name)
+		save.Show()
+	})
+}
@@ -0,0 +1,1113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+// errUsage is returned by a command whose arguments could not be parsed. The
+// flag package has already printed what was wrong.
+var errUsage = errors.New("usage") //nolint:gochecknoglobals
+
+// cliCommand is one subcommand of the command-line interface.
+type cliCommand struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, dbase database.Database, args []string) error
+}
+
+func cliCommands() []cliCommand {
+	return []cliCommand{
+		{"gui", "open the graphical interface (the default)", nil},
+		{"alerts", "list the new issues that matched the watchlist", cliAlerts},
+		{"changes", "report the series added, removed, restored or changed by refreshes", cliChanges},
+		{"creators", "list creators, or show one's issues and collaborators and merge their aliases", cliCreators},
+		{"export", "write the series or issues matching a filter as CSV, JSON or JSON Lines", cliExport},
+		{"filter", "list the series or issues matching genres, imprints, creators and more", cliFilter},
+		{"issues", "list the issues published on DCUI in a date range", cliIssues},
+		{"orders", "build, edit, list and export reading orders of events and storylines", cliOrders},
+		{"plans", "report the issues whose subscription plans changed, such as those moved to the base tier", cliPlans},
+		{"refresh", "download the DCUI catalog into the database", cliRefresh},
+		{"search", "find series and issues by the words in their titles and descriptions", cliSearch},
+		{"stats", "summarize what is in the database", cliStats},
+		{"watch", "list, add to or remove from the watchlist", cliWatch},
+	}
+}
+
+// runCLI runs the subcommand named by args[0] and returns the exit status.
+func runCLI(ctx context.Context, dbase database.Database, args []string) int {
+	name := args[0]
+
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		cliUsage(os.Stdout)
+
+		return 0
+	}
+
+	for _, cmd := range cliCommands() {
+		if cmd.name != name || cmd.run == nil {
+			continue
+		}
+
+		mainLog.Printf("running %v %v\n", name, args[1:])
+
+		err := cmd.run(ctx, dbase, args[1:])
+
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			return 2
+		case errors.Is(err, context.Canceled):
+			fmt.Fprintln(os.Stderr, "dcui-scraper: cancelled")
+
+			return 1
+		case err != nil:
+			mainLog.Println(err)
+			fmt.Fprintln(os.Stderr, "dcui-scraper:", err)
+
+			return 1
+		}
+
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "dcui-scraper: unknown command %q\n", name)
+	cliUsage(os.Stderr)
+
+	return 2
+}
+
+func cliUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: dcui-scraper [flags] [command] [command flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range cliCommands() {
+		fmt.Fprintf(tw, "  %v\t%v\n", cmd.name, cmd.summary)
+	}
+
+	_ = tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "flags, each of which can also be set by the environment variable shown:")
+
+	var (
+		file string
+		cfg  database.Config
+	)
+
+	fs := configFlagSet(&file, &cfg)
+	fs.SetOutput(w)
+	fs.VisitAll(func(f *flag.Flag) {
+		f.Usage += " (" + envName(f.Name) + ")"
+	})
+	fs.PrintDefaults()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `run "dcui-scraper <command> -h" for a command's flags`)
+}
+
+// parseFlags parses args into fs, returning errUsage if they are invalid. Only
+// flags are accepted.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := parseFlagsAndArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %v\n", fs.Args())
+		fs.Usage()
+
+		return errUsage
+	}
+
+	return nil
+}
+
+// parseFlagsAndArgs parses args into fs, returning errUsage if they are
+// invalid. Arguments after the flags are left in fs.Args.
+func parseFlagsAndArgs(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+
+		return errUsage
+	}
+
+	return nil
+}
+
+func cliRefresh(ctx context.Context, dbase database.Database, args []string) error {
+	fs := flag.NewFlagSet("refresh", flag.ContinueOnError)
+	full := fs.Bool("full", false, "download the details of every series, not just new or changed ones")
+	allOrNothing := fs.Bool("all-or-nothing", false, "keep none of the refresh unless all of it succeeds")
+	restart := fs.Bool("restart", false, "start over instead of resuming an interrupted refresh")
+	workers := fs.Int("workers", refreshWorkers, "number of series to download at once")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("refreshing database; progress is written to", mainLogPath)
+
+	start := time.Now()
+
+	err = dbase.RefreshDatabase(ctx, database.RefreshOptions{
+		Incremental:  !*full,
+		AllOrNothing: *allOrNothing,
+		Restart:      *restart,
+		Workers:      *workers,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("refresh complete in %v\n", time.Since(start).Round(time.Second))
+
+	return nil
+}
+
+func cliStats(ctx context.Context, dbase database.Database, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	stats, err := dbase.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	lastRefresh := "never"
+	if !stats.LastRefresh.IsZero() {
+		lastRefresh = stats.LastRefresh.Format(time.DateTime)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "series:\t%v\n", stats.Series)
+	fmt.Fprintf(tw, "issues:\t%v\n", stats.Issues)
+	fmt.Fprintf(tw, "creators:\t%v\n", stats.Creators)
+	fmt.Fprintf(tw, "genres:\t%v\n", stats.Genres)
+	fmt.Fprintf(tw, "imprints:\t%v\n", stats.Imprints)
+	fmt.Fprintf(tw, "series needing update:\t%v\n", stats.NeedUpdate)
+	fmt.Fprintf(tw, "series removed from DCUI:\t%v\n", stats.RemovedSeries)
+	fmt.Fprintf(tw, "last refresh:\t%v\n", lastRefresh)
+
+	if stats.RefreshInterrupted {
+		fmt.Fprintln(tw, "interrupted refresh:\tyes, the next refresh will resume it")
+	}
+
+	return tw.Flush()
+}
+
+func cliSearch(ctx context.Context, dbase database.Database, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	title := fs.Bool("title", false, "only list series whose titles contain the words, in title order")
+	limit := fs.Int("limit", 50, "most matches to list; 0 lists them all") //nolint:mnd
+	includeRemoved := fs.Bool("removed", false, "include series and issues removed from DCUI")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: dcui-scraper search [flags] words...")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlagsAndArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	query := strings.Join(fs.Args(), " ")
+
+	if *title {
+		series, err := dbase.SearchSeries(ctx, query, *includeRemoved)
+		if err != nil {
+			return err
+		}
+
+		return printSeries(series)
+	}
+
+	hits, err := dbase.Search(ctx, query, *limit, *includeRemoved)
+	if err != nil {
+		return err
+	}
+
+	if !dbase.FullTextSearch() {
+		fmt.Fprintln(os.Stderr, "note: built without FTS5, so using the basic LIKE search; build with make to "+
+			"rank matches properly")
+	}
+
+	bold, plain := "*", "*"
+	if isTerminal(os.Stdout) {
+		bold, plain = "\x1b[1m", "\x1b[0m"
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tTITLE\tSERIES\tMATCH")
+
+	for _, h := range hits {
+		var snippet strings.Builder
+
+		for _, p := range h.Snippet {
+			if p.Match {
+				snippet.WriteString(bold + p.Text + plain)
+			} else {
+				snippet.WriteString(p.Text)
+			}
+		}
+
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", h.Kind, hitTitle(h), h.SeriesTitle, snippet.String())
+	}
+
+	return tw.Flush()
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func cliIssues(ctx context.Context, dbase database.Database, args []string) error {
+	fs := flag.NewFlagSet("issues", flag.ContinueOnError)
+	from := fs.String("from", "", "first publication date, YYYY-MM-DD")
+	to := fs.String("to", "", "last publication date, YYYY-MM-DD")
+	days := fs.Int("days", 0, "published in this many days up to today; overrides -from and -to")
+	includeRemoved := fs.Bool("removed", false, "include issues removed from DCUI")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *days > 0 {
+		today := time.Now()
+		*from = today.AddDate(0, 0, -*days).Format(time.DateOnly)
+		*to = today.Format(time.DateOnly)
+	}
+
+	start, end, err := dateRange(*from, *to)
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+
+		return errUsage
+	}
+
+	issues, err := dbase.IssuesPublished(ctx, start, end, *includeRemoved)
+	if err != nil {
+		return err
+	}
+
+	return printIssues(issues)
+}
+
+func cliFilter(ctx context.Context, dbase database.Database, args []string) error {
+	spec := newFilterSpec()
+
+	fs := flag.NewFlagSet("filter", flag.ContinueOnError)
+	issues := fs.Bool("issues", false, "list matching issues instead of series")
+	addFilterFlags(fs, &spec)
+	limit := fs.Int("limit", 100, "most results to list; 0 lists them all") //nolint:mnd
+	page := fs.Int("page", 1, "page of results to list")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	filter, err := spec.filter()
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+
+		return errUsage
+	}
+
+	opts := database.QueryOptions{
+		Limit:          *limit,
+		Offset:         max(*page-1, 0) * max(*limit, 0),
+		IncludeRemoved: spec.includeRemoved,
+	}
+
+	var shown, total int
+
+	if *issues {
+		var results []database.Issue
+
+		results, total, err = dbase.QueryIssues(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+
+		shown = len(results)
+		err = printIssues(results)
+	} else {
+		var results []database.Series
+
+		results, total, err = dbase.QuerySeries(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+
+		shown = len(results)
+		err = printSeries(results)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if shown < total {
+		fmt.Fprintf(os.Stderr, "listed %v to %v of %v; use -page for more\n", opts.Offset+1, opts.Offset+shown, total)
+	}
+
+	return nil
+}
+
+// addFilterFlags adds the flags that fill in spec to fs.
+func addFilterFlags(fs *flag.FlagSet, spec *filterSpec) {
+	fs.Var((*listFlag)(&spec.genres), "genre", "in the genre; may be repeated or a comma-separated list")
+	fs.Var((*listFlag)(&spec.imprints), "imprint", "under the imprint; may be repeated or a comma-separated list")
+	fs.Var((*listFlag)(&spec.creators), "creator", "by the creator; may be repeated or a comma-separated list")
+	fs.Var((*listFlag)(&spec.tags), "tag", "with the tag; may be repeated or a comma-separated list")
+	fs.StringVar(&spec.title, "title", "", "with a title containing this text")
+	fs.IntVar(&spec.minIssues, "min-issues", -1, "in a series with at least this many issues")
+	fs.IntVar(&spec.maxIssues, "max-issues", -1, "in a series with at most this many issues")
+	fs.StringVar(&spec.from, "from", "", "with an issue published on or after this date, YYYY-MM-DD")
+	fs.StringVar(&spec.to, "to", "", "with an issue published on or before this date, YYYY-MM-DD")
+	fs.Var((*listFlag)(&spec.plans), "plan", "exclusive to the subscription plan, such as ultra; may be repeated")
+	fs.StringVar(&spec.movedSince, "moved-since", "", "moved to the base tier on or after this date, YYYY-MM-DD")
+	fs.BoolVar(&spec.scheduled, "scheduled", false, "scheduled to be added to DCUI")
+	fs.BoolVar(&spec.matchAny, "any", false, "match any of the conditions given instead of all of them")
+	fs.BoolVar(&spec.includeRemoved, "removed", false, "include series and issues removed from DCUI")
+}
+
+func cliExport(ctx context.Context, dbase database.Database, args []string) error {
+	spec := newFilterSpec()
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	issues := fs.Bool("issues", false, "export matching issues, with creators and tags, instead of series")
+	output := fs.String("o", "", "file to write to instead of standard output; its extension sets the format")
+	format := fs.String("format", "", "csv, json or jsonl; defaults to the output file's extension, or csv")
+	addFilterFlags(fs, &spec)
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	filter, err := spec.filter()
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+
+		return errUsage
+	}
+
+	exportFormat, err := parseExportFormat(*format, *output)
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+
+		return errUsage
+	}
+
+	w := os.Stdout
+	if *output != "" {
+		w, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+
+	var (
+		n    int
+		opts = database.QueryOptions{IncludeRemoved: spec.includeRemoved}
+	)
+
+	if *issues {
+		n, err = dbase.ExportIssues(ctx, w, exportFormat, filter, opts)
+	} else {
+		n, err = dbase.ExportSeries(ctx, w, exportFormat, filter, opts)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		err = w.Close()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "exported %v to %v\n", n, *output)
+	}
+
+	return nil
+}
+
+// parseExportFormat returns the export format named by format, or if it is
+// empty the one given by the extension of the output file, or CSV.
+func parseExportFormat(format, output string) (database.ExportFormat, error) {
+	if format == "" {
+		format = string(database.ExportCSV)
+		if ext := filepath.Ext(output); ext != "" {
+			format = ext
+		}
+	}
+
+	return database.ParseExportFormat(format)
+}
+
+func cliChanges(ctx context.Context, dbase database.Database, args []string) error {
+	fs := flag.NewFlagSet("changes", flag.ContinueOnError)
+	runID := fs.Int64("run", 0, "report the changes from this refresh instead of the last one to finish")
+	since := fs.String("since", "", "report the changes from every refresh since this date, YYYY-MM-DD")
+	listRuns := fs.Bool("runs", false, "list recent refreshes instead")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *listRuns {
+		runs, err := dbase.RefreshRuns(ctx, 20) //nolint:mnd
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "RUN\tSTARTED\tFINISHED\tSTATUS\tKIND\tCHANGES")
+
+		for _, r := range runs {
+			finished := ""
+			if !r.Finished.IsZero() {
+				finished = r.Finished.Format(time.DateTime)
+			}
+
+			kind := "full"
+			if r.Incremental {
+				kind = "incremental"
+			}
+
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", r.ID, r.Started.Format(time.DateTime), finished, r.Status,
+				kind, r.Changes)
+		}
+
+		return tw.Flush()
+	}
+
+	var changes []database.Change
+
+	if *since != "" {
+		start, _, err := dateRange(*since, "")
+		if err != nil {
+			fmt.Fprintln(fs.Output(), err)
+			fs.Usage()
+
+			return errUsage
+		}
+
+		changes, err = dbase.ChangesSince(ctx, start)
+		if err != nil {
+			return err
+		}
+	} else {
+		changes, err = dbase.Changes(ctx, *runID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("no changes")
+
+		return nil
+	}
+
+	headings := map[string]string{
+		database.ChangeNew:                "New series",
+		database.ChangeRemoved:            "Removed series",
+		database.ChangeRestored:           "Restored series",
+		database.ChangeBookCountIncreased: "Series with new books",
+		database.ChangeDescription:        "Series with new descriptions",
+	}
+
+	// Changes come grouped by kind, so a heading is printed whenever the
+	// kind changes.
+	for i, c := range changes {
+		if i == 0 || c.Kind != changes[i-1].Kind {
+			n := 0
+			for _, other := range changes[i:] {
+				if other.Kind == c.Kind {
+					n++
+				}
+			}
+
+			if i > 0 {
+				fmt.Println()
+			}
+
+			fmt.Printf("%v (%v):\n", headings[c.Kind], n)
+		}
+
+		switch c.Kind {
+		case database.ChangeBookCountIncreased:
+			fmt.Printf("  %v: %v -> %v books\n", c.SeriesTitle, c.OldValue, c.NewValue)
+		default:
+			fmt.Printf("  %v\n", c.SeriesTitle)
+		}
+	}
+
+	return nil
+}
+
+func cliPlans(ctx context.Context, dbase database.Database, args []string) error {
+	fs := flag.NewFlagSet("plans", flag.ContinueOnError)
+	since := fs.String("since", monthStart(time.Now()), "report the changes found on or after this date, YYYY-MM-DD")
+	moved := fs.Bool("moved", false, "only report issues moved to the base tier")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	start, _, err := dateRange(*since, "")
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+
+		return errUsage
+	}
+
+	changes, err := dbase.PlanChanges(ctx, start, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	if *moved {
+		changes = slices.DeleteFunc(changes, func(c database.PlanChange) bool {
+			return !c.MovedToBaseTier()
+		})
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("no plan changes")
+
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANGED\tSERIES\tISSUE\tTITLE\tWAS\tNOW\tURL")
+
+	for _, c := range changes {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", c.Changed.Format(time.DateOnly), seriesTitle(c.Issue.Series),
+			c.Issue.IssueNumber, issueTitle(c.Issue), planNames(c.OldPlans), planNames(c.NewPlans), c.Issue.URL)
+	}
+
+	return tw.Flush()
+}
+
+func cliAlerts(ctx context.Context, dbase database.Database, args []string) error {
+	fs := flag.NewFlagSet("alerts", flag.ContinueOnError)
+	dismiss := fs.Bool("dismiss", false, "dismiss the alerts listed so they are not listed again")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	alerts, err := dbase.Alerts(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	if len(alerts) == 0 {
+		fmt.Println("no alerts")
+
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RAISED\tWATCHING\tSERIES\tISSUE\tTITLE\tURL")
+
+	for _, a := range alerts {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", a.Created.Format(time.DateOnly), a.Reason(),
+			seriesTitle(a.Issue.Series), a.Issue.IssueNumber, issueTitle(a.Issue), a.Issue.URL)
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	if !*dismiss {
+		return nil
+	}
+
+	// Only the alerts listed are dismissed, not any raised since by a
+	// refresh running alongside.
+	for _, a := range alerts {
+		err = dbase.DismissAlert(ctx, a.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cliWatch(ctx context.Context, dbase database.Database, args []string) error {
+	var lists [4][]string
+
+	kinds := [...]string{database.WatchSeries, database.WatchCreator, database.WatchGenre, database.WatchImprint}
+
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.Var((*listFlag)(&lists[0]), kinds[0], "watch the series with this UUID; may be repeated or a comma-separated list")
+	fs.Var((*listFlag)(&lists[1]), kinds[1],
+		"watch the creator with this name or display name; may be repeated or a comma-separated list")
+	fs.Var((*listFlag)(&lists[2]), kinds[2], "watch the genre; may be repeated or a comma-separated list")
+	fs.Var((*listFlag)(&lists[3]), kinds[3], "watch the imprint; may be repeated or a comma-separated list")
+	remove := fs.Bool("remove", false, "remove those given from the watchlist instead")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+
+	for i, values := range lists {
+		for _, v := range values {
+			if *remove {
+				err = dbase.RemoveWatch(ctx, kinds[i], v)
+			} else {
+				err = dbase.AddWatch(ctx, kinds[i], v)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			changed = true
+		}
+	}
+
+	if changed {
+		return nil
+	}
+
+	watches, err := dbase.Watches(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(watches) == 0 {
+		fmt.Println("the watchlist is empty")
+
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tWATCHING\tSINCE")
+
+	for _, w := range watches {
+		value := w.Value
+		if w.Title != "" {
+			value = w.Title + " (" + w.Value + ")"
+		}
+
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", w.Kind, value, w.Added.Format(time.DateOnly))
+	}
+
+	return tw.Flush()
+}
+
+func cliCreators(ctx context.Context, dbase database.Database, args []string) error {
+	fs := flag.NewFlagSet("creators", flag.ContinueOnError)
+	id := fs.Int64("id", 0, "show the issues and top collaborators of the creator with this ID")
+	merge := fs.Int64("merge", 0, "merge the creator with this ID into the one given by -id")
+	top := fs.Int("top", 10, "most collaborators to show with -id") //nolint:mnd
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: dcui-scraper creators [flags] [name]")
+		fs.PrintDefaults()
+	}
+
+	err := parseFlagsAndArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *merge != 0 {
+		if *id == 0 {
+			fmt.Fprintln(fs.Output(), "-merge needs -id")
+			fs.Usage()
+
+			return errUsage
+		}
+
+		err = dbase.MergeCreators(ctx, *id, *merge)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *id != 0 {
+		return printCreator(ctx, dbase, *id, *top)
+	}
+
+	creators, err := dbase.Creators(ctx, strings.Join(fs.Args(), " "))
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tISSUES\tROLES")
+
+	for _, c := range creators {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", c.ID, c.DisplayName, c.Issues, roleSummary(c.Roles))
+	}
+
+	return tw.Flush()
+}
+
+// printCreator prints a creator's aliases and roles, their issues by role
+// and series, and up to top collaborators.
+func printCreator(ctx context.Context, dbase database.Database, id int64, top int) error {
+	c, err := dbase.Creator(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	credits, err := dbase.CreatorCredits(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	collaborators, err := dbase.TopCollaborators(ctx, id, top)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%v: %v issues as %v\n", c.DisplayName, c.Issues, roleSummary(c.Roles))
+	fmt.Printf("credited as %v\n", strings.Join(c.Aliases, ", "))
+
+	role := ""
+
+	for _, g := range credits {
+		if g.Role != role {
+			role = g.Role
+			fmt.Printf("\n%v\n", role)
+		}
+
+		numbers := make([]string, len(g.Issues))
+		for i, issue := range g.Issues {
+			numbers[i] = "#" + issue.IssueNumber
+		}
+
+		fmt.Printf("  %v: %v\n", seriesTitle(g.Series), strings.Join(numbers, ", "))
+	}
+
+	if len(collaborators) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCOLLABORATOR\tSHARED ISSUES")
+
+	for _, collaborator := range collaborators {
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", collaborator.ID, collaborator.DisplayName, collaborator.SharedIssues)
+	}
+
+	return tw.Flush()
+}
+
+func cliOrders(ctx context.Context, dbase database.Database, args []string) error {
+	var add []string
+
+	fs := flag.NewFlagSet("orders", flag.ContinueOnError)
+	tags := fs.Bool("tags", false, "list the event, storyline and other tags that orders can be built from")
+	build := fs.String("build", "", "build, or rebuild, the reading order of the issues with this tag")
+	category := fs.String("category", "event", "the category of the tag given by -build")
+	create := fs.String("new", "", "create an empty reading order with this name")
+	id := fs.Int64("id", 0, "list, add to, export or delete the reading order with this ID")
+	fs.Var((*listFlag)(&add), "add", "add the issue with this UUID to the end of the order given by -id; "+
+		"may be repeated or a comma-separated list")
+	output := fs.String("o", "", "export the order given by -id to this file; its extension sets the format")
+	format := fs.String("format", "", "export the order given by -id as csv, json or jsonl")
+	remove := fs.Bool("delete", false, "delete the order given by -id")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var (
+		edits   = len(add) > 0 || *remove || *output != "" || *format != ""
+		creates = *build != "" || *create != ""
+		problem string
+	)
+
+	switch {
+	case *tags && fs.NFlag() > 1:
+		problem = "-tags cannot be combined with other flags"
+	case *build != "" && *create != "":
+		problem = "-build and -new cannot be combined"
+	case creates && *id != 0:
+		problem = "-id cannot be combined with -build or -new, which give the order an ID of its own"
+	case *remove && (creates || len(add) > 0 || *output != "" || *format != ""):
+		problem = "-delete can only be combined with -id"
+	case edits && *id == 0 && !creates:
+		problem = "-add, -delete, -o and -format need -id, -build or -new"
+	}
+
+	if problem != "" {
+		fmt.Fprintln(fs.Output(), problem)
+		fs.Usage()
+
+		return errUsage
+	}
+
+	switch {
+	case *tags:
+		return printTagGroups(ctx, dbase)
+	case *build != "":
+		*id, err = dbase.BuildReadingOrder(ctx, *category, *build)
+	case *create != "":
+		*id, err = dbase.CreateReadingOrder(ctx, *create)
+	case *id == 0:
+		return printReadingOrders(ctx, dbase)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, uuid := range add {
+		err = dbase.AddToReadingOrder(ctx, *id, uuid)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *remove {
+		return dbase.DeleteReadingOrder(ctx, *id)
+	}
+
+	if *output == "" && *format == "" {
+		issues, err := dbase.ReadingOrderIssues(ctx, *id)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("reading order %v\n", *id)
+
+		return printOrderIssues(issues)
+	}
+
+	exportFormat, err := parseExportFormat(*format, *output)
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+
+		return errUsage
+	}
+
+	// The order is exported before the file is created, so that exporting an
+	// unknown order does not leave an empty file behind.
+	var buf bytes.Buffer
+
+	n, err := dbase.ExportReadingOrder(ctx, &buf, exportFormat, *id)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = buf.WriteTo(os.Stdout)
+
+		return err
+	}
+
+	w, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %v to %v\n", n, *output)
+
+	return nil
+}
+
+func printTagGroups(ctx context.Context, dbase database.Database) error {
+	groups, err := dbase.TagGroups(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tTAG\tISSUES")
+
+	for _, g := range groups {
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", g.Category, g.Name, g.Issues)
+	}
+
+	return tw.Flush()
+}
+
+func printReadingOrders(ctx context.Context, dbase database.Database) error {
+	orders, err := dbase.ReadingOrders(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tBUILT FROM\tISSUES\tUPDATED")
+
+	for _, o := range orders {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\n", o.ID, o.Name, tagName(o.Tag), o.Issues,
+			o.Updated.Format(time.DateOnly))
+	}
+
+	return tw.Flush()
+}
+
+func printOrderIssues(issues []database.Issue) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tSERIES\tISSUE\tTITLE\tURL")
+
+	for n, i := range issues {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\n", n+1, seriesTitle(i.Series), i.IssueNumber, issueTitle(i), i.URL)
+	}
+
+	return tw.Flush()
+}
+
+func printSeries(series []database.Series) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TITLE\tISSUES\tVOLUMES\tOMNIBUSES\tURL")
+
+	for _, s := range series {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\n", seriesTitle(s), s.IssueCount, s.VolumeCount, s.OmnibusCount, s.URL)
+	}
+
+	return tw.Flush()
+}
+
+func printIssues(issues []database.Issue) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PUBLISHED\tSERIES\tISSUE\tTITLE\tPLANS\tURL")
+
+	for _, i := range issues {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", publishedDate(i), seriesTitle(i.Series), i.IssueNumber,
+			issueTitle(i), planNames(i.Plans), i.URL)
+	}
+
+	return tw.Flush()
+}
+
+// seriesTitle is the title of a series as listed, marked if it has been
+// removed from DCUI.
+func seriesTitle(s database.Series) string {
+	if s.DateRemoved.IsZero() {
+		return s.Title
+	}
+
+	return s.Title + " (removed)"
+}
+
+// issueTitle is the title of an issue as listed, marked if it has been
+// removed from DCUI.
+func issueTitle(i database.Issue) string {
+	if i.DateRemoved.IsZero() {
+		return i.Title
+	}
+
+	return i.Title + " (removed)"
+}
+
+// hitTitle is the title of a search hit as listed, marked if it has been
+// removed from DCUI.
+func hitTitle(h database.SearchHit) string {
+	if !h.Removed {
+		return h.Title
+	}
+
+	return h.Title + " (removed)"
+}
+
+// roleSummary lists a creator's roles with their issue counts, such as
+// "author (12), penciller (3)".
+func roleSummary(roles []database.RoleCount) string {
+	parts := make([]string, len(roles))
+	for i, r := range roles {
+		parts[i] = fmt.Sprintf("%v (%v)", r.Role, r.Issues)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// tagName describes the tag a reading order was built from, such as
+// "event: Rebirth", or returns "" for orders made by hand.
+func tagName(t database.TagGroup) string {
+	if t.Name == "" {
+		return ""
+	}
+
+	return t.Category + ": " + t.Name
+}
+
+// planNames lists the subscription plans an issue is exclusive to, or
+// returns "base" for an issue in every plan.
+func planNames(plans []string) string {
+	if len(plans) == 0 {
+		return "base"
+	}
+
+	return strings.Join(plans, ", ")
+}
+
+// publishedDate formats the day an issue was published, or is empty if that
+// is not known.
+func publishedDate(i database.Issue) string {
+	if i.PublicationDate.IsZero() {
+		return ""
+	}
+
+	return i.PublicationDate.UTC().Format(time.DateOnly)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+func TestParseConfigFlags(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		db   string
+		want []string
+	}{
+		{nil, "", nil},
+		{[]string{"gui"}, "", []string{"gui"}},
+		{[]string{"-db", "other.db"}, "other.db", nil},
+		{[]string{"-db", "other.db", "refresh", "-full"}, "other.db", []string{"refresh", "-full"}},
+		{[]string{"search", "-db", "batman"}, "", []string{"search", "-db", "batman"}},
+	} {
+		flags, args, err := parseConfigFlags(test.args)
+		if err != nil {
+			t.Fatalf("parseConfigFlags(%q): %v", test.args, err)
+		}
+
+		if db := flags.Lookup("db").Value.String(); db != test.db || !slices.Equal(args, test.want) {
+			t.Errorf("parseConfigFlags(%q) = -db %q and %q, want %q and %q", test.args, db, args, test.db,
+				test.want)
+		}
+	}
+
+	_, _, err := parseConfigFlags([]string{"-unknown", "refresh"})
+	if !errors.Is(err, errUsage) {
+		t.Errorf("parseConfigFlags with an unknown flag returned %v, want %v", err, errUsage)
+	}
+
+	_, _, err = parseConfigFlags([]string{"-h"})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("parseConfigFlags(-h) returned %v, want %v", err, flag.ErrHelp)
+	}
+}
+
+func TestRunCLIUsage(t *testing.T) {
+	mainLog = log.New(io.Discard, "", 0)
+
+	// None of these reach the database.
+	for _, test := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"help"}, 0},
+		{[]string{"-h"}, 0},
+		{[]string{"stats", "-h"}, 0},
+		{[]string{"unknown"}, 2},
+		{[]string{"stats", "extra"}, 2},
+		{[]string{"refresh", "-workers", "many"}, 2},
+		{[]string{"search", "-unknown", "batman"}, 2},
+		{[]string{"export", "-format", "xml"}, 2},
+		{[]string{"orders", "-tags", "-id", "1"}, 2},
+		{[]string{"orders", "-tags", "-o", "tags.csv"}, 2},
+		{[]string{"orders", "-build", "Rebirth", "-new", "Mine"}, 2},
+		{[]string{"orders", "-build", "Rebirth", "-id", "1"}, 2},
+		{[]string{"orders", "-new", "Mine", "-id", "1"}, 2},
+		{[]string{"orders", "-build", "Rebirth", "-delete"}, 2},
+		{[]string{"orders", "-id", "1", "-delete", "-o", "order.csv"}, 2},
+		{[]string{"orders", "-id", "1", "-delete", "-add", "b0000001"}, 2},
+		{[]string{"orders", "-delete"}, 2},
+	} {
+		got := runCLI(context.Background(), database.Database{}, test.args)
+		if got != test.want {
+			t.Errorf("runCLI(%q) = %v, want %v", test.args, got, test.want)
+		}
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	for _, test := range []struct {
+		format string
+		output string
+		want   database.ExportFormat
+		ok     bool
+	}{
+		{"csv", "", database.ExportCSV, true},
+		{"", "series.json", database.ExportJSON, true},
+		{"", "series.jsonl", database.ExportJSONL, true},
+		{"", "series.txt", "", false},
+		{"xml", "", "", false},
+	} {
+		got, err := parseExportFormat(test.format, test.output)
+		if (err == nil) != test.ok || (test.ok && got != test.want) {
+			t.Errorf("parseExportFormat(%q, %q) = %v, %v, want %v", test.format, test.output, got, err, test.want)
+		}
+	}
+}
+
+func TestOrdersExportUnknown(t *testing.T) {
+	mainLog = log.New(io.Discard, "", 0)
+
+	dir := t.TempDir()
+
+	dbase, err := database.New(database.Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbase.Close()
+
+	output := filepath.Join(dir, "order.csv")
+
+	code := runCLI(context.Background(), dbase, []string{"orders", "-id", "7", "-o", output})
+	if code != 1 {
+		t.Errorf("exporting an unknown order exited with %v, want 1", code)
+	}
+
+	_, err = os.Stat(output)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("exporting an unknown order left %v behind: %v", output, err)
+	}
+}
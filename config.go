@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+// configFlagSet returns the flags given before the command, which set the
+// config file to read and override the settings in it. Each flag can also
+// be set by an environment variable, see envName, which the flag overrides.
+func configFlagSet(file *string, cfg *database.Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("dcui-scraper", flag.ContinueOnError)
+	fs.Usage = func() { cliUsage(fs.Output()) }
+
+	fs.StringVar(file, "config", "", "TOML config file; defaults to config.toml in ~/.dcui if it exists")
+	fs.StringVar(&cfg.Dir, "dir", cfg.Dir, "directory for the database and logs; defaults to ~/.dcui")
+	fs.StringVar(&cfg.Database, "db", cfg.Database, "database file; defaults to dcui.db in the directory")
+	fs.StringVar(&cfg.LogDir, "log-dir", cfg.LogDir, "log directory; defaults to logs in the directory")
+	fs.StringVar(&cfg.SearchURL, "search-url", cfg.SearchURL, "DCUI search engine endpoint")
+	fs.StringVar(&cfg.ComicsURL, "comics-url", cfg.ComicsURL, "DCUI comics API endpoint")
+	fs.Float64Var(&cfg.RateLimit, "rate-limit", cfg.RateLimit,
+		"most API requests a second, default 20; negative for no limit")
+	fs.IntVar(&cfg.Burst, "burst", cfg.Burst, "most API requests made at once, default 1")
+	fs.DurationVar(&cfg.HTTPTimeout, "timeout", cfg.HTTPTimeout, "time limit on each API request, default 1m")
+	fs.DurationVar(&cfg.RetryDelay, "retry-delay", cfg.RetryDelay,
+		"longest wait between retries of a failed request, default 30s")
+	fs.IntVar(&cfg.PageSize, "page-size", cfg.PageSize, "series requested from the search engine at once, default 100")
+
+	return fs
+}
+
+// envName is the environment variable setting the flag named name, e.g.
+// DCUI_LOG_DIR for -log-dir.
+func envName(name string) string {
+	return "DCUI_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// parseConfigFlags parses the flags before the command in args, returning
+// the flags that were set and the command with its arguments.
+func parseConfigFlags(args []string) (*flag.FlagSet, []string, error) {
+	var (
+		file string
+		cfg  database.Config
+	)
+
+	fs := configFlagSet(&file, &cfg)
+
+	err := parseFlagsAndArgs(fs, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fs, fs.Args(), nil
+}
+
+// loadConfig builds the configuration from, in increasing priority, the
+// defaults, the config file, the environment and the flags set in flags.
+func loadConfig(flags *flag.FlagSet) (database.Config, error) {
+	var (
+		file string
+		cfg  database.Config
+	)
+
+	// The config file is chosen first, as everything else overrides it.
+	file = os.Getenv(envName("config"))
+	if f := flags.Lookup("config"); f.Value.String() != "" {
+		file = f.Value.String()
+	}
+
+	if file != "" {
+		loaded, err := database.LoadConfig(file)
+		if err != nil {
+			return cfg, err
+		}
+
+		cfg = loaded
+	} else {
+		userHome, err := os.UserHomeDir()
+		if err != nil {
+			return cfg, err
+		}
+
+		loaded, err := database.LoadConfig(filepath.Join(userHome, ".dcui", "config.toml"))
+
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return cfg, err
+		default:
+			cfg = loaded
+		}
+	}
+
+	target := configFlagSet(&file, &cfg)
+
+	var err error
+
+	target.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || f.Name == "config" || err != nil {
+			return
+		}
+
+		err = target.Set(f.Name, value)
+		if err != nil {
+			err = fmt.Errorf("%v: %w", envName(f.Name), err)
+		}
+	})
+
+	if err != nil {
+		return cfg, err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		if err == nil {
+			err = target.Set(f.Name, f.Value.String())
+		}
+	})
+
+	return cfg, err
+}
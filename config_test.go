@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+// clearConfigEnv unsets the environment variables setting the config for the
+// rest of the test, with a home directory of its own.
+func clearConfigEnv(t *testing.T) string {
+	t.Helper()
+
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, "DCUI_") {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	return home
+}
+
+// writeConfig writes a config file with the TOML text to path.
+func writeConfig(t *testing.T, path, text string) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(path, []byte(text), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// resolveConfig loads and resolves the config for the flags in args the way
+// main does.
+func resolveConfig(t *testing.T, args ...string) (database.Config, error) {
+	t.Helper()
+
+	flags, _, err := parseConfigFlags(args)
+	if err != nil {
+		t.Fatalf("parseConfigFlags(%q): %v", args, err)
+	}
+
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return cfg, err
+	}
+
+	return cfg.Resolve()
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	home := clearConfigEnv(t)
+
+	cfg, err := resolveConfig(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(home, ".dcui")
+	if cfg.Dir != dir || cfg.RateLimit != 20 || cfg.Burst != 1 || cfg.PageSize != 100 ||
+		cfg.HTTPTimeout != time.Minute || cfg.RetryDelay != 30*time.Second {
+		t.Errorf("defaults = %+v, want the database's defaults in %v", cfg, dir)
+	}
+
+	// The config file in ~/.dcui overrides the defaults.
+	writeConfig(t, filepath.Join(dir, "config.toml"), `dir = "/srv/dcui"
+rateLimit = 5
+burst = 3
+pageSize = 50
+httpTimeout = "10s"
+`)
+
+	cfg, err = resolveConfig(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Dir != "/srv/dcui" || cfg.RateLimit != 5 || cfg.Burst != 3 || cfg.PageSize != 50 ||
+		cfg.HTTPTimeout != 10*time.Second || cfg.RetryDelay != 30*time.Second {
+		t.Errorf("config from the file = %+v, want its settings over the defaults", cfg)
+	}
+
+	// The environment overrides the file.
+	t.Setenv("DCUI_RATE_LIMIT", "2")
+	t.Setenv("DCUI_PAGE_SIZE", "25")
+	t.Setenv("DCUI_TIMEOUT", "5s")
+
+	cfg, err = resolveConfig(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.RateLimit != 2 || cfg.PageSize != 25 || cfg.HTTPTimeout != 5*time.Second || cfg.Burst != 3 {
+		t.Errorf("config from the environment = %+v, want it over the file", cfg)
+	}
+
+	// The flags override the environment.
+	cfg, err = resolveConfig(t, "-rate-limit", "1", "-timeout", "2s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.RateLimit != 1 || cfg.HTTPTimeout != 2*time.Second || cfg.PageSize != 25 || cfg.Burst != 3 ||
+		cfg.Dir != "/srv/dcui" {
+		t.Errorf("config from the flags = %+v, want them over the environment", cfg)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	home := clearConfigEnv(t)
+
+	writeConfig(t, filepath.Join(home, ".dcui", "config.toml"), "pageSize = 10\n")
+
+	envFile := filepath.Join(home, "env.toml")
+	writeConfig(t, envFile, "pageSize = 20\n")
+
+	flagFile := filepath.Join(home, "flag.toml")
+	writeConfig(t, flagFile, "pageSize = 30\n")
+
+	t.Setenv("DCUI_CONFIG", envFile)
+
+	cfg, err := resolveConfig(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.PageSize != 20 {
+		t.Errorf("DCUI_CONFIG read a page size of %v, want 20", cfg.PageSize)
+	}
+
+	cfg, err = resolveConfig(t, "-config", flagFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.PageSize != 30 {
+		t.Errorf("-config read a page size of %v, want 30", cfg.PageSize)
+	}
+
+	// Only the default file may be missing.
+	_, err = resolveConfig(t, "-config", filepath.Join(home, "missing.toml"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a missing -config file returned %v, want an error", err)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		file string
+		env  map[string]string
+		want string
+	}{
+		{"bad env value", "", map[string]string{"DCUI_BURST": "many"}, "DCUI_BURST"},
+		{"bad env duration", "", map[string]string{"DCUI_RETRY_DELAY": "soon"}, "DCUI_RETRY_DELAY"},
+		{"bad file value", `pageSize = "lots"`, nil, "pageSize"},
+		{"unknown file key", "perPage = 50", nil, "perPage"},
+		{"bad file syntax", "pageSize =", nil, "line 2"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			home := clearConfigEnv(t)
+
+			if test.file != "" {
+				writeConfig(t, filepath.Join(home, ".dcui", "config.toml"), test.file+"\n")
+			}
+
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := resolveConfig(t)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("loading the config returned %v, want an error naming %v", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+// topCollaborators is how many collaborators creatorDetails lists.
+const topCollaborators = 10
+
+// creatorIndex shows the creators whose names contain the text entered,
+// updating as it is typed.
+func (b *browser) creatorIndex() {
+	mainLog.Println("showing creator index")
+
+	count := widget.NewLabel("")
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Creator name")
+	entry.OnChanged = func(name string) {
+		creators, err := b.dbase.Creators(b.ctx, name)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		count.SetText(fmt.Sprintf("%v creators", len(creators)))
+		b.showOutput(b.creatorTable(creators))
+	}
+
+	b.showOptions(widget.NewLabel("Name contains:"), entry, count)
+	entry.OnChanged("")
+	b.window.Canvas().Focus(entry)
+}
+
+// creatorColumns are the headings of the columns in creatorTable.
+var creatorColumns = []string{"ID", "Name", "Issues", "Roles"} //nolint:gochecknoglobals
+
+// creatorTable lists creators with their roles. Selecting one shows their
+// issues and collaborators.
+func (b *browser) creatorTable(creators []database.IndexedCreator) *widget.Table {
+	table := widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(creators), len(creatorColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, cell fyne.CanvasObject) {
+			c := creators[id.Row]
+
+			var text string
+
+			switch id.Col {
+			case 0:
+				text = strconv.FormatInt(c.ID, 10)
+			case 1:
+				text = c.DisplayName
+			case 2:
+				text = strconv.Itoa(c.Issues)
+			case 3:
+				text = roleSummary(c.Roles)
+			}
+
+			label := cell.(*widget.Label) //nolint:forcetypeassert
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.SetText(text)
+		})
+
+	table.ShowHeaderColumn = false
+	table.UpdateHeader = func(id widget.TableCellID, cell fyne.CanvasObject) {
+		cell.(*widget.Label).SetText(creatorColumns[id.Col]) //nolint:forcetypeassert
+	}
+
+	table.SetColumnWidth(0, 60)
+	table.SetColumnWidth(1, 240)
+	table.SetColumnWidth(2, 60)
+	table.SetColumnWidth(3, 420)
+
+	table.OnSelected = func(id widget.TableCellID) {
+		b.creatorDetails(creators[id.Row].ID)
+		table.Unselect(id)
+	}
+
+	return table
+}
+
+// creatorDetails shows a creator's issues grouped by role and series, with
+// their aliases, top collaborators and a form to merge another creator into
+// them in the options pane.
+func (b *browser) creatorDetails(id int64) {
+	mainLog.Printf("showing creator %v\n", id)
+
+	c, err := b.dbase.Creator(b.ctx, id)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	credits, err := b.dbase.CreatorCredits(b.ctx, id)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	collaborators, err := b.dbase.TopCollaborators(b.ctx, id, topCollaborators)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	about := widget.NewLabel(fmt.Sprintf("%v issues as %v.\nCredited as %v.", c.Issues, roleSummary(c.Roles),
+		strings.Join(c.Aliases, ", ")))
+	about.Wrapping = fyne.TextWrapWord
+
+	options := []fyne.CanvasObject{widget.NewLabel(c.DisplayName), about, widget.NewLabel("Top collaborators:")}
+
+	for _, collaborator := range collaborators {
+		options = append(options, widget.NewButton(
+			fmt.Sprintf("%v (%v issues)", collaborator.DisplayName, collaborator.SharedIssues), func() {
+				b.creatorDetails(collaborator.ID)
+			}))
+	}
+
+	merge := widget.NewEntry()
+	merge.SetPlaceHolder("ID of a creator to merge")
+
+	mergeButton := widget.NewButton("Merge", func() {
+		from, err := strconv.ParseInt(strings.TrimSpace(merge.Text), 10, 64)
+		if err != nil {
+			b.showError(fmt.Errorf("creator ID %q: %w", merge.Text, err))
+
+			return
+		}
+
+		dialog.ShowConfirm("Merge creators",
+			fmt.Sprintf("Credit everything by creator %v to %v?", from, c.DisplayName), func(ok bool) {
+				if !ok {
+					return
+				}
+
+				err := b.dbase.MergeCreators(b.ctx, id, from)
+				if err != nil {
+					b.showError(err)
+
+					return
+				}
+
+				b.creatorDetails(id)
+			}, b.window)
+	})
+
+	options = append(options, widget.NewSeparator(),
+		widget.NewLabel("Merge a variant of this creator's name into it:"), merge, mergeButton)
+
+	b.showOptions(options...)
+	b.showOutput(container.NewBorder(nil,
+		container.NewHBox(b.exportButton(database.ByCreatorID(id), true, database.QueryOptions{IncludeRemoved: true})),
+		nil, nil, b.creditTree(credits)))
+}
+
+// creditTree shows a creator's credits as a tree of roles, then series, then
+// issues. Selecting an issue shows what has been recorded about reading it.
+func (b *browser) creditTree(credits []database.CreditGroup) *widget.Tree {
+	children := map[string][]string{}
+	labels := map[string]string{}
+	issues := map[string]database.Issue{}
+
+	for _, g := range credits {
+		roleID := g.Role
+		if _, ok := labels[roleID]; !ok {
+			children[""] = append(children[""], roleID)
+			labels[roleID] = g.Role
+		}
+
+		seriesID := roleID + "/" + g.Series.UUID
+		children[roleID] = append(children[roleID], seriesID)
+		labels[seriesID] = fmt.Sprintf("%v (%v)", seriesTitle(g.Series), len(g.Issues))
+
+		for _, i := range g.Issues {
+			issueID := seriesID + "/" + i.UUID
+			children[seriesID] = append(children[seriesID], issueID)
+			labels[issueID] = issueTitle(i)
+			issues[issueID] = i
+		}
+	}
+
+	tree := widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			return children[id]
+		},
+		func(id widget.TreeNodeID) bool {
+			_, ok := issues[id]
+
+			return !ok
+		},
+		func(bool) fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TreeNodeID, _ bool, node fyne.CanvasObject) {
+			node.(*widget.Label).SetText(labels[id]) //nolint:forcetypeassert
+		})
+
+	tree.OnSelected = func(id widget.TreeNodeID) {
+		if i, ok := issues[id]; ok {
+			b.issueDetails(i)
+		}
+
+		tree.Unselect(id)
+	}
+
+	for _, role := range children[""] {
+		tree.OpenBranch(role)
+	}
+
+	return tree
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,10 +12,10 @@ import (
 )
 
 const (
-	retryDelay  = 30 * time.Second
-	apiDelay    = 50 * time.Millisecond
-	httpTimeout = time.Minute
-	startPage   = 1
+	defaultRetryDelay  = 30 * time.Second
+	defaultHTTPTimeout = time.Minute
+	startPage          = 1
+	defaultPageSize    = 100
 )
 
 type apiResponseError struct {
@@ -30,14 +31,13 @@ func (e apiResponseError) Is(target error) bool {
 	return target == apiResponseError{}
 }
 
-func (db Database) getAllSeries() ([]SearchResult, error) {
-	db.log.Println("getting all series from DCUI API")
-
-	const recordsPerPage = 100
+// getSeriesPage requests a single page of perPage series from the search
+// engine.
+func (db Database) getSeriesPage(ctx context.Context, page, perPage int) (SearchResult, error) {
 	reqBody := SearchBody{
-		EngineKey:     engineKey, // engineKey is in creds.go, not synced due to security concerns
-		Page:          startPage,
-		PerPage:       recordsPerPage,
+		EngineKey:     db.api.EngineKey,
+		Page:          page,
+		PerPage:       perPage,
 		DocumentTypes: []string{"comicseries"},
 		Filters:       map[string]string{},
 		SortField: map[string]string{
@@ -48,47 +48,22 @@ func (db Database) getAllSeries() ([]SearchResult, error) {
 		},
 	}
 
-	db.log.Printf("retrieving first %v records\n", recordsPerPage)
+	db.log.Printf("retrieving page %v of series\n", page)
 
-	singleResult, err := db.requestSeries(reqBody)
+	result, err := db.requestSeries(ctx, reqBody)
 	if err != nil {
-		err = fmt.Errorf("database.getAllSeries: %w", err)
+		err = fmt.Errorf("database.getSeriesPage: %w", err)
 		db.log.Println(err)
 
-		return nil, err
-	}
-
-	searchResults := []SearchResult{singleResult}
-	numPages := singleResult.Info.ComicSeries.NumPages
-	nextPage := startPage + 1
-
-	for p := nextPage; p <= numPages; p++ {
-		time.Sleep(apiDelay)
-		db.log.Printf("retrieving records %v/%v\n", p*recordsPerPage, singleResult.Info.ComicSeries.TotalResultCount)
-
-		reqBody.Page = p
-
-		singleResult, err = db.requestSeries(reqBody)
-		if err != nil {
-			err = fmt.Errorf("database.getAllSeries: %w", err)
-			db.log.Println(err)
-
-			return nil, err
-		}
-
-		searchResults = append(searchResults, singleResult)
+		return result, err
 	}
 
-	db.log.Println("done getting all series")
-
-	return searchResults, nil
+	return result, nil
 }
 
-func (db Database) requestSeries(reqBody SearchBody) (SearchResult, error) {
+func (db Database) requestSeries(ctx context.Context, reqBody SearchBody) (SearchResult, error) {
 	db.log.Println("requesting series")
 
-	const uri = "https://search.dcuniverseinfinite.com/api/v1/public/engines/search.json"
-
 	var searchResult SearchResult
 
 	jsonData, err := json.Marshal(reqBody)
@@ -99,7 +74,7 @@ func (db Database) requestSeries(reqBody SearchBody) (SearchResult, error) {
 		return searchResult, err
 	}
 
-	resp, err := post(uri, jsonData)
+	resp, err := db.api.post(ctx, db.api.SearchURL, jsonData)
 	if err != nil {
 		err = fmt.Errorf("database.requestSeries: %w", err)
 		db.log.Println(err)
@@ -122,35 +97,17 @@ func (db Database) requestSeries(reqBody SearchBody) (SearchResult, error) {
 	return searchResult, nil
 }
 
-func post(uri string, data []byte) ([]byte, error) {
-	httpClient := &http.Client{
-		Timeout: httpTimeout,
-	}
-
-	resp, err := httpClient.Post(uri, "application/json", bytes.NewBuffer(data))
-	if err != nil {
-		time.Sleep(retryDelay)
-
-		resp, err = httpClient.Post(uri, "application/json", bytes.NewBuffer(data))
+func (c *APIClient) post(ctx context.Context, uri string, data []byte) ([]byte, error) {
+	body, err := c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(data))
 		if err != nil {
-			err = fmt.Errorf("database.post: %w", err)
-
 			return nil, err
 		}
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		err = apiResponseError{
-			statusCode: resp.StatusCode,
-			status:     resp.Status,
-		}
 
-		return nil, err
-	}
+		req.Header.Set("Content-Type", "application/json")
 
-	body, err := io.ReadAll(resp.Body)
+		return req, nil
+	})
 	if err != nil {
 		err = fmt.Errorf("database.post: %w", err)
 
@@ -160,12 +117,12 @@ func post(uri string, data []byte) ([]byte, error) {
 	return body, nil
 }
 
-func (db Database) getSeriesDescription(uuid string) (string, error) {
+func (db Database) getSeriesDescription(ctx context.Context, uuid string) (string, error) {
 	db.log.Println("getting series description")
 
-	uri := fmt.Sprintf("https://www.dcuniverseinfinite.com/api/comics/1/series/%v/?trans=en", uuid)
+	uri := db.api.comicsURL(fmt.Sprintf("/series/%v/?trans=en", uuid))
 
-	resp, err := get(uri)
+	resp, err := db.api.get(ctx, uri)
 	if err != nil {
 		err = fmt.Errorf("database.getSeriesDescription: %w", err)
 		db.log.Println(err)
@@ -190,49 +147,180 @@ func (db Database) getSeriesDescription(uuid string) (string, error) {
 	return seriesDetail.Description, nil
 }
 
-func get(uri string) ([]byte, error) {
-	httpClient := &http.Client{
-		Timeout: httpTimeout,
+func (db Database) getSeriesBooks(ctx context.Context, uuid string) ([]BookDetailsValues, error) {
+	db.log.Println("getting series books")
+
+	var books []BookDetailsValues
+
+	for p := startPage; ; p++ {
+		uri := db.api.comicsURL(fmt.Sprintf("/series/%v/books/?trans=en&page=%v", uuid, p))
+
+		resp, err := db.api.get(ctx, uri)
+		if err != nil {
+			err = fmt.Errorf("database.getSeriesBooks: %w", err)
+			db.log.Println(err)
+			db.log.Println(uri)
+
+			return nil, err
+		}
+
+		var bookDetails BookDetails
+
+		err = json.Unmarshal(resp, &bookDetails)
+		if err != nil {
+			err = fmt.Errorf("database.getSeriesBooks: %w", err)
+			db.log.Println(err)
+			db.log.Println(string(resp))
+
+			return nil, err
+		}
+
+		books = append(books, bookDetails.Values...)
+
+		db.log.Printf("retrieved books page %v/%v\n", p, bookDetails.NumPages)
+
+		if p >= bookDetails.NumPages {
+			break
+		}
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, uri, nil)
+	db.log.Println("series books retrieved")
+
+	return books, nil
+}
+
+func (c *APIClient) get(ctx context.Context, uri string) ([]byte, error) {
+	body, err := c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Add("X-Consumer-Key", c.ConsumerKey)
+
+		return req, nil
+	})
 	if err != nil {
 		err = fmt.Errorf("database.get: %w", err)
 
 		return nil, err
 	}
 
-	req.Header.Add("X-Consumer-Key", xConsumerKey)
+	return body, nil
+}
+
+// do sends the request built by newRequest, retrying according to the
+// client's retry policy, and returns the body of the first 200 response.
+// Every attempt waits its turn under the client's rate limit.
+// Any other final status is returned as an apiResponseError.
+func (c *APIClient) do(ctx context.Context, newRequest func() (*http.Request, error)) ([]byte, error) {
+	httpClient := c.httpClient()
+	policy := c.Retry
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		time.Sleep(retryDelay)
+	for attempt := 1; ; attempt++ {
+		err := c.wait(ctx)
+		if err != nil {
+			err = fmt.Errorf("database.do: %w", err)
+
+			return nil, err
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			err = fmt.Errorf("database.do: %w", err)
+
+			return nil, err
+		}
+
+		body, wait, err := c.attempt(httpClient, req)
+		if err == nil {
+			return body, nil
+		}
+
+		if ctx.Err() != nil {
+			err = fmt.Errorf("database.do: %w", ctx.Err())
+
+			return nil, err
+		}
+
+		var respErr apiResponseError
+
+		retryable := true
+		if errors.As(err, &respErr) {
+			retryable = policy.retryableStatus(respErr.statusCode)
+		}
+
+		if wait > policy.MaxRetryAfter {
+			c.log.Printf("%v %v: %v, Retry-After %v is too long to wait\n", req.Method, req.URL, err, wait)
+
+			retryable = false
+		}
+
+		if !retryable || attempt >= policy.MaxAttempts {
+			c.log.Printf("%v %v: attempt %v/%v failed, giving up: %v\n",
+				req.Method, req.URL, attempt, policy.MaxAttempts, err)
+
+			if respErr != (apiResponseError{}) {
+				return nil, respErr
+			}
+
+			err = fmt.Errorf("database.do: %w", err)
 
-		resp, err = httpClient.Do(req)
+			return nil, err
+		}
+
+		wait = max(wait, policy.backoff(attempt))
+
+		c.log.Printf("%v %v: attempt %v/%v failed, retrying in %v: %v\n",
+			req.Method, req.URL, attempt, policy.MaxAttempts, wait.Round(time.Millisecond), err)
+
+		err = sleep(ctx, wait)
 		if err != nil {
-			err = fmt.Errorf("database.get: %w", err)
+			err = fmt.Errorf("database.do: %w", err)
 
 			return nil, err
 		}
 	}
+}
+
+// attempt sends req once. For non-200 responses it returns an
+// apiResponseError along with any wait requested by a Retry-After header.
+func (c *APIClient) attempt(httpClient *http.Client, req *http.Request) ([]byte, time.Duration, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		wait, _ := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+
 		err = apiResponseError{
 			statusCode: resp.StatusCode,
 			status:     resp.Status,
 		}
 
-		return nil, err
+		return nil, wait, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		err = fmt.Errorf("database.get: %w", err)
-
-		return nil, err
+		return nil, 0, err
 	}
 
-	return body, nil
+	return body, 0, nil
+}
+
+// sleep pauses for d, returning early with ctx's error if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
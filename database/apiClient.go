@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSearchURL = "https://search.dcuniverseinfinite.com/api/v1/public/engines/search.json"
+	defaultComicsURL = "https://www.dcuniverseinfinite.com/api/comics/1"
+	defaultRateLimit = 20
+)
+
+// APIClient holds the endpoints, transport and credentials used to talk to
+// the DCUI search engine and comics API. Any field may be changed before the
+// client is handed to NewWithClient, e.g. to point the scraper at a mirror
+// or a local test server.
+type APIClient struct {
+	// SearchURL is the full URL of the search engine's search.json endpoint.
+	SearchURL string
+	// ComicsURL is the base URL of the comics API; series and book paths are
+	// appended to it.
+	ComicsURL string
+	// Transport performs the HTTP requests. http.DefaultTransport is used if
+	// it is nil.
+	Transport http.RoundTripper
+	// EngineKey is sent in the body of every search request.
+	EngineKey string
+	// ConsumerKey is sent in the X-Consumer-Key header of every comics API
+	// request.
+	ConsumerKey string
+	// Timeout limits each HTTP request, including reading the response body.
+	Timeout time.Duration
+	// Retry decides which failed requests are retried and how long to wait
+	// between attempts.
+	Retry RetryPolicy
+	// RateLimit is the most requests per second made to either endpoint,
+	// across every worker, with up to Burst requests made at once. A
+	// RateLimit of zero or less disables limiting.
+	RateLimit float64
+	Burst     int
+
+	log         *log.Logger
+	limiterOnce sync.Once
+	limiter     *rateLimiter
+}
+
+// NewAPIClient returns a client for the public DCUI endpoints using the
+// credentials compiled into the binary.
+func NewAPIClient() *APIClient {
+	return &APIClient{
+		SearchURL:   defaultSearchURL,
+		ComicsURL:   defaultComicsURL,
+		Transport:   http.DefaultTransport,
+		EngineKey:   engineKey, // engineKey is in creds.go, not synced due to security concerns
+		ConsumerKey: xConsumerKey,
+		Timeout:     defaultHTTPTimeout,
+		Retry:       DefaultRetryPolicy(),
+		RateLimit:   defaultRateLimit,
+		Burst:       1,
+		log:         log.New(io.Discard, "", 0),
+	}
+}
+
+// withLog returns a new client with the same settings as c that logs to
+// logger, leaving c as it was. It has its own rate limiter.
+func (c *APIClient) withLog(logger *log.Logger) *APIClient {
+	return &APIClient{
+		SearchURL:   c.SearchURL,
+		ComicsURL:   c.ComicsURL,
+		Transport:   c.Transport,
+		EngineKey:   c.EngineKey,
+		ConsumerKey: c.ConsumerKey,
+		Timeout:     c.Timeout,
+		Retry:       c.Retry,
+		RateLimit:   c.RateLimit,
+		Burst:       c.Burst,
+		log:         logger,
+	}
+}
+
+func (c *APIClient) httpClient() *http.Client {
+	return &http.Client{
+		Transport: c.Transport,
+		Timeout:   c.Timeout,
+	}
+}
+
+// wait blocks until the rate limit allows another request.
+func (c *APIClient) wait(ctx context.Context) error {
+	c.limiterOnce.Do(func() {
+		c.limiter = newRateLimiter(c.RateLimit, c.Burst)
+	})
+
+	return c.limiter.wait(ctx)
+}
+
+func (c *APIClient) comicsURL(path string) string {
+	return strings.TrimSuffix(c.ComicsURL, "/") + path
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RefreshRuns returns up to limit of the most recent refreshes, newest first.
+// A limit of zero or less returns them all.
+func (db Database) RefreshRuns(ctx context.Context, limit int) ([]RefreshRun, error) {
+	db.log.Println("getting refresh runs")
+
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := db.database.QueryContext(ctx, queries["selectRefreshRuns"], limit)
+	if err != nil {
+		err = fmt.Errorf("database.RefreshRuns: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []RefreshRun
+
+	for rows.Next() {
+		var (
+			run               RefreshRun
+			started, finished int64
+		)
+
+		err = rows.Scan(&run.ID, &run.Incremental, &run.Status, &started, &finished, &run.Changes)
+		if err != nil {
+			err = fmt.Errorf("database.RefreshRuns: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+
+		run.Started = time.Unix(started, 0)
+		if finished > 0 {
+			run.Finished = time.Unix(finished, 0)
+		}
+
+		runs = append(runs, run)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("database.RefreshRuns: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// Changes returns the series changes recorded by refresh runID, or by the
+// last refresh to finish if runID is zero, grouped by kind: new series, then
+// removed series, then restored series, then book count increases, then
+// description changes.
+func (db Database) Changes(ctx context.Context, runID int64) ([]Change, error) {
+	if runID == 0 {
+		err := db.database.QueryRowContext(ctx, queries["selectLastCompleteRun"]).Scan(&runID)
+		if err != nil {
+			err = fmt.Errorf("database.Changes: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+	}
+
+	db.log.Printf("getting changes from refresh %v\n", runID)
+
+	changes, err := db.selectChanges(ctx, "c.runID = ?", runID)
+	if err != nil {
+		err = fmt.Errorf("database.Changes: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// ChangesSince returns the series changes recorded by every refresh since
+// the given time, grouped by kind like Changes.
+func (db Database) ChangesSince(ctx context.Context, since time.Time) ([]Change, error) {
+	db.log.Printf("getting changes since %v\n", since)
+
+	changes, err := db.selectChanges(ctx, "c.dateChanged >= ?", since.Unix())
+	if err != nil {
+		err = fmt.Errorf("database.ChangesSince: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (db Database) selectChanges(ctx context.Context, cond string, args ...any) ([]Change, error) {
+	rows, err := db.database.QueryContext(ctx, fmt.Sprintf(queries["selectChangesWhere"], cond), args...)
+	if err != nil {
+		return nil, fmt.Errorf("database.selectChanges: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []Change
+
+	for rows.Next() {
+		var (
+			c    Change
+			date int64
+		)
+
+		err = rows.Scan(&c.RunID, &date, &c.Kind, &c.SeriesUUID, &c.SeriesTitle, &c.OldValue, &c.NewValue)
+		if err != nil {
+			return nil, fmt.Errorf("database.selectChanges: %w", err)
+		}
+
+		c.Date = time.Unix(date, 0)
+
+		changes = append(changes, c)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("database.selectChanges: %w", err)
+	}
+
+	return changes, nil
+}
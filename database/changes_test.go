@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// changeStrings describes each change as kind:title:old:new.
+func changeStrings(changes []Change) []string {
+	var got []string
+	for _, c := range changes {
+		got = append(got, fmt.Sprintf("%v:%v:%v:%v", c.Kind, c.SeriesTitle, c.OldValue, c.NewValue))
+	}
+
+	return got
+}
+
+func TestChanges(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	changes, err := db.Changes(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "changes before any refresh", changeStrings(changes), nil)
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err = db.Changes(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "first refresh changes", changeStrings(changes), []string{
+		"new:Batman (2016)::Batman (2016)",
+		"new:Harley Quinn's Greatest Hits::Harley Quinn's Greatest Hits",
+		"new:Swamp Thing::Swamp Thing",
+	})
+
+	// Swamp Thing is pulled from DCUI, Batman gains a book and Harley Quinn's
+	// description is rewritten.
+	fs.editFixture(t, "search", "page2", func(fixture map[string]any) {
+		fixture["records"].(map[string]any)["comicseries"] = []any{}
+	})
+	fs.editFixture(t, "search", "page1", func(fixture map[string]any) {
+		fixture["records"].(map[string]any)["comicseries"].([]any)[0].(map[string]any)["books_count"] = 3
+	})
+	fs.editFixture(t, "series", harleyUUID, func(fixture map[string]any) {
+		fixture["description"] = "Harley's greatest moments, remastered."
+	})
+
+	for range 2 {
+		err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := db.RefreshRuns(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(runs) != 3 || runs[0].ID <= runs[1].ID || runs[1].Changes != 3 || runs[2].Changes != 3 ||
+		runs[0].Changes != 0 || runs[0].Status != "complete" || runs[0].Finished.IsZero() {
+		t.Fatalf("RefreshRuns = %+v, want 3 complete runs newest first with 0, 3 and 3 changes", runs)
+	}
+
+	changes, err = db.Changes(context.Background(), runs[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "second refresh changes", changeStrings(changes), []string{
+		"removed:Swamp Thing:Swamp Thing:",
+		"bookCountIncreased:Batman (2016):2:3",
+		"descriptionChanged:Harley Quinn's Greatest Hits:The best of Harley's 'greatest' moments.:" +
+			"Harley's greatest moments, remastered.",
+	})
+
+	changes, err = db.ChangesSince(context.Background(), time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 6 {
+		t.Errorf("ChangesSince an hour ago = %v, want all 6", changeStrings(changes))
+	}
+}
+
+func TestChangesIgnoreEmptyCatalog(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A search engine returning nothing is a fault, not every series being
+	// pulled.
+	for _, page := range []string{"page1", "page2"} {
+		fs.editFixture(t, "search", page, func(fixture map[string]any) {
+			fixture["records"].(map[string]any)["comicseries"] = []any{}
+		})
+	}
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := db.Changes(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "changes", changeStrings(changes), nil)
+}
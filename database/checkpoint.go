@@ -0,0 +1,85 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Refresh phases, in the order a run goes through them.
+const (
+	// phaseSeries pages through the search engine storing every series.
+	phaseSeries = "series"
+	// phaseDetails downloads descriptions and issues for flagged series.
+	phaseDetails = "details"
+)
+
+// refreshRun is the checkpoint of a refresh, stored in the refreshRun table
+// so that a refresh interrupted by an error, or by the app being closed, can
+// resume where it stopped.
+type refreshRun struct {
+	id          int64
+	incremental bool
+	phase       string
+	// page is the last search page stored, and pageSize the number of series
+	// on each page, which must not change while resuming.
+	page     int
+	pageSize int
+	// lastSeriesUUID is the last series whose details were stored.
+	lastSeriesUUID string
+}
+
+// startRun resumes the most recent interrupted run, or starts a new one if
+// there is none or opts.Restart is set.
+func (db Database) startRun(q querier, opts RefreshOptions) (refreshRun, error) {
+	if opts.Restart {
+		db.log.Println("abandoning interrupted refreshes")
+
+		_, err := q.Exec(queries["abandonRuns"], time.Now().Unix())
+		if err != nil {
+			err = fmt.Errorf("database.startRun: %w", err)
+
+			return refreshRun{}, err
+		}
+	}
+
+	var run refreshRun
+
+	err := q.QueryRow(queries["selectInterruptedRun"]).Scan(
+		&run.id, &run.incremental, &run.phase, &run.page, &run.pageSize, &run.lastSeriesUUID)
+	if err == nil {
+		db.log.Printf("resuming refresh %v from %v phase, page %v, series %q\n",
+			run.id, run.phase, run.page, run.lastSeriesUUID)
+
+		return run, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		err = fmt.Errorf("database.startRun: %w", err)
+
+		return run, err
+	}
+
+	result, err := q.Exec(queries["insertRun"], opts.Incremental, db.pageSize, time.Now().Unix())
+	if err != nil {
+		err = fmt.Errorf("database.startRun: %w", err)
+
+		return run, err
+	}
+
+	run.id, err = result.LastInsertId()
+	if err != nil {
+		err = fmt.Errorf("database.startRun: %w", err)
+
+		return run, err
+	}
+
+	run.incremental = opts.Incremental
+	run.pageSize = db.pageSize
+	run.phase = phaseSeries
+
+	db.log.Printf("starting refresh %v\n", run.id)
+
+	return run, nil
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config says where a Database keeps its files and how it talks to DCUI. It
+// can be read from a TOML file with LoadConfig. Unset, zero, fields take the
+// defaults given, so separate databases need only differ in Dir.
+type Config struct {
+	// Dir holds the database and logs unless they are set on their own.
+	// Defaults to ~/.dcui.
+	Dir string `toml:"dir"`
+	// Database is the SQLite database file. Defaults to dcui.db in Dir.
+	Database string `toml:"database"`
+	// LogDir is where dcui-scraper.log is written. Defaults to logs in Dir.
+	LogDir string `toml:"logDir"`
+	// SearchURL and ComicsURL are the DCUI endpoints; see APIClient.
+	SearchURL string `toml:"searchURL"`
+	ComicsURL string `toml:"comicsURL"`
+	// RateLimit is the most API requests a second, with up to Burst made at
+	// once. Defaults to 20 and 1; a negative RateLimit disables limiting.
+	RateLimit float64 `toml:"rateLimit"`
+	Burst     int     `toml:"burst"`
+	// HTTPTimeout limits each API request. Defaults to a minute.
+	HTTPTimeout time.Duration `toml:"httpTimeout"`
+	// RetryDelay is the longest wait between retries of a failed request.
+	// Defaults to 30 seconds.
+	RetryDelay time.Duration `toml:"retryDelay"`
+	// PageSize is how many series are requested from the search engine at
+	// once. Defaults to 100.
+	PageSize int `toml:"pageSize"`
+}
+
+var errConfigKeys = errors.New("unknown config keys")
+
+// LoadConfig reads a Config from the TOML file at path. Keys it does not
+// know are an error, so that a misspelt setting is not silently ignored.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+
+	md, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("database.LoadConfig: %w", err)
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, k := range undecoded {
+			keys[i] = k.String()
+		}
+
+		return cfg, fmt.Errorf("database.LoadConfig: %w in %v: %v", errConfigKeys, path,
+			strings.Join(keys, ", "))
+	}
+
+	return cfg, nil
+}
+
+// Resolve returns cfg with every unset field set to its default.
+func (cfg Config) Resolve() (Config, error) {
+	if cfg.Dir == "" {
+		userHome, err := os.UserHomeDir()
+		if err != nil {
+			return cfg, fmt.Errorf("database.Resolve: %w", err)
+		}
+
+		cfg.Dir = userHome + sep + ".dcui"
+	}
+
+	if cfg.Database == "" {
+		cfg.Database = cfg.Dir + sep + "dcui.db"
+	}
+
+	if cfg.LogDir == "" {
+		cfg.LogDir = cfg.Dir + sep + "logs"
+	}
+
+	if cfg.SearchURL == "" {
+		cfg.SearchURL = defaultSearchURL
+	}
+
+	if cfg.ComicsURL == "" {
+		cfg.ComicsURL = defaultComicsURL
+	}
+
+	if cfg.RateLimit == 0 {
+		cfg.RateLimit = defaultRateLimit
+	}
+
+	if cfg.Burst <= 0 {
+		cfg.Burst = 1
+	}
+
+	if cfg.HTTPTimeout <= 0 {
+		cfg.HTTPTimeout = defaultHTTPTimeout
+	}
+
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = defaultRetryDelay
+	}
+
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = defaultPageSize
+	}
+
+	return cfg, nil
+}
+
+// apiClient returns a client for the endpoints, rate limit and timeouts of
+// cfg, which must be resolved.
+func (cfg Config) apiClient() *APIClient {
+	client := NewAPIClient()
+	client.SearchURL = cfg.SearchURL
+	client.ComicsURL = cfg.ComicsURL
+	client.RateLimit = cfg.RateLimit
+	client.Burst = cfg.Burst
+	client.Timeout = cfg.HTTPTimeout
+	client.Retry.MaxBackoff = cfg.RetryDelay
+
+	return client
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+
+	err := os.WriteFile(path, []byte(`dir = "/srv/dcui"
+rateLimit = -1
+httpTimeout = "10s"
+pageSize = 50
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = cfg.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Config{
+		Dir:         "/srv/dcui",
+		Database:    "/srv/dcui" + sep + "dcui.db",
+		LogDir:      "/srv/dcui" + sep + "logs",
+		SearchURL:   defaultSearchURL,
+		ComicsURL:   defaultComicsURL,
+		RateLimit:   -1,
+		Burst:       1,
+		HTTPTimeout: 10 * time.Second,
+		RetryDelay:  defaultRetryDelay,
+		PageSize:    50,
+	}
+	if cfg != want {
+		t.Errorf("LoadConfig = %+v, want %+v", cfg, want)
+	}
+
+	err = os.WriteFile(path, []byte("perPage = 50\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadConfig(path)
+	if !errors.Is(err, errConfigKeys) {
+		t.Errorf("LoadConfig with an unknown key returned %v, want %v", err, errConfigKeys)
+	}
+}
+
+func TestConfigSeparateDatabases(t *testing.T) {
+	fs := newFixtureServer(t)
+	newTestHome(t)
+
+	open := func(cfg Config) Database {
+		db, err := NewWithClient(cfg, fs.client())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(db.Close)
+
+		return db
+	}
+
+	refreshed := open(Config{Dir: t.TempDir(), PageSize: 50})
+	untouched := open(Config{Database: filepath.Join(t.TempDir(), "other.db")})
+
+	err := refreshed.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "refresh page size", queryStrings(t, refreshed, "SELECT pageSize FROM refreshRun"),
+		[]string{"50"})
+	assertStrings(t, "series in the other database", queryStrings(t, untouched, "SELECT title FROM series"),
+		nil)
+}
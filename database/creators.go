@@ -0,0 +1,341 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	errUnknownCreator = errors.New("no such creator")
+	errMergeSelf      = errors.New("cannot merge a creator into itself")
+)
+
+// IndexedCreator is a creator in the creator index. Refreshes file every name
+// DCUI credits under an indexed creator, grouping names whose display names
+// differ only in case, spaces, periods and hyphens. Other variants can be
+// grouped with MergeCreators.
+type IndexedCreator struct {
+	ID          int64
+	DisplayName string
+	// Issues counts the issues credited to the creator in any role.
+	Issues int
+	Roles  []RoleCount
+	// Aliases are the names the creator is credited under, such as
+	// "tom-king". Only Creator sets them.
+	Aliases []string
+}
+
+// RoleCount is the number of issues credited to a creator in a role, such as
+// "author" or "penciller".
+type RoleCount struct {
+	Role   string
+	Issues int
+}
+
+// CreditGroup is the issues of one series credited to a creator in one role.
+type CreditGroup struct {
+	Role   string
+	Series Series
+	Issues []Issue
+}
+
+// Collaborator is a creator credited on issues with another.
+type Collaborator struct {
+	ID          int64
+	DisplayName string
+	// SharedIssues counts the issues both creators are credited on.
+	SharedIssues int
+}
+
+// Creators returns the indexed creators with a display name or alias
+// containing text, ignoring case, in display name order.
+func (db Database) Creators(ctx context.Context, text string) ([]IndexedCreator, error) {
+	pattern := "%" + escapeLike(text) + "%"
+
+	rows, err := db.database.QueryContext(ctx, queries["selectCreators"], pattern, pattern)
+	if err != nil {
+		err = fmt.Errorf("database.Creators: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var creators []IndexedCreator
+
+	for rows.Next() {
+		var (
+			c    IndexedCreator
+			role RoleCount
+		)
+
+		err = rows.Scan(&c.ID, &c.DisplayName, &c.Issues, &role.Role, &role.Issues)
+		if err != nil {
+			err = fmt.Errorf("database.Creators: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+
+		// Rows come a role at a time, each creator's together.
+		if len(creators) == 0 || creators[len(creators)-1].ID != c.ID {
+			creators = append(creators, c)
+		}
+
+		last := &creators[len(creators)-1]
+		last.Roles = append(last.Roles, role)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("database.Creators: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return creators, nil
+}
+
+// Creator returns the indexed creator with the ID, with their aliases.
+func (db Database) Creator(ctx context.Context, id int64) (IndexedCreator, error) {
+	c := IndexedCreator{ID: id}
+
+	err := db.database.QueryRowContext(ctx, queries["selectCreator"], id).Scan(&c.DisplayName, &c.Issues)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = fmt.Errorf("%w: %v", errUnknownCreator, id)
+	}
+
+	if err == nil {
+		c.Roles, err = db.creatorRoles(ctx, id)
+	}
+
+	if err == nil {
+		c.Aliases, err = db.creatorAliases(ctx, id)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("database.Creator: %w", err)
+		db.log.Println(err)
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+// creatorRoles returns the number of issues credited to the creator in each
+// role.
+func (db Database) creatorRoles(ctx context.Context, id int64) ([]RoleCount, error) {
+	rows, err := db.database.QueryContext(ctx, queries["selectCreatorRoles"], id)
+	if err != nil {
+		return nil, fmt.Errorf("database.creatorRoles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []RoleCount
+
+	for rows.Next() {
+		var r RoleCount
+
+		err = rows.Scan(&r.Role, &r.Issues)
+		if err != nil {
+			return nil, fmt.Errorf("database.creatorRoles: %w", err)
+		}
+
+		roles = append(roles, r)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("database.creatorRoles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// creatorAliases returns the names the creator is credited under.
+func (db Database) creatorAliases(ctx context.Context, id int64) ([]string, error) {
+	rows, err := db.database.QueryContext(ctx, queries["selectCreatorAliases"], id)
+	if err != nil {
+		return nil, fmt.Errorf("database.creatorAliases: %w", err)
+	}
+	defer rows.Close()
+
+	var aliases []string
+
+	for rows.Next() {
+		var alias string
+
+		err = rows.Scan(&alias)
+		if err != nil {
+			return nil, fmt.Errorf("database.creatorAliases: %w", err)
+		}
+
+		aliases = append(aliases, alias)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("database.creatorAliases: %w", err)
+	}
+
+	return aliases, nil
+}
+
+// CreatorCredits returns the issues credited to the indexed creator, grouped
+// by role and then series, in series title and issue number order.
+func (db Database) CreatorCredits(ctx context.Context, id int64) ([]CreditGroup, error) {
+	rows, err := db.database.QueryContext(ctx, queries["selectCreatorCredits"], id)
+	if err != nil {
+		err = fmt.Errorf("database.CreatorCredits: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []CreditGroup
+
+	for rows.Next() {
+		var (
+			i    Issue
+			role string
+		)
+
+		i, err = scanIssue(rows, &role)
+		if err != nil {
+			err = fmt.Errorf("database.CreatorCredits: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+
+		n := len(groups)
+		if n == 0 || groups[n-1].Role != role || groups[n-1].Series.UUID != i.Series.UUID {
+			groups = append(groups, CreditGroup{Role: role, Series: i.Series})
+			n++
+		}
+
+		groups[n-1].Issues = append(groups[n-1].Issues, i)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("database.CreatorCredits: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// TopCollaborators returns up to limit creators credited on the most issues
+// with the indexed creator, most shared issues first.
+func (db Database) TopCollaborators(ctx context.Context, id int64, limit int) ([]Collaborator, error) {
+	rows, err := db.database.QueryContext(ctx, queries["selectTopCollaborators"], id, limit)
+	if err != nil {
+		err = fmt.Errorf("database.TopCollaborators: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var collaborators []Collaborator
+
+	for rows.Next() {
+		var c Collaborator
+
+		err = rows.Scan(&c.ID, &c.DisplayName, &c.SharedIssues)
+		if err != nil {
+			err = fmt.Errorf("database.TopCollaborators: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+
+		collaborators = append(collaborators, c)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("database.TopCollaborators: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return collaborators, nil
+}
+
+// MergeCreators files every alias of the creator from under the creator into,
+// which keeps its display name, and removes from from the index. Names with
+// the same display name as any of the aliases are filed under into by later
+// refreshes too.
+func (db Database) MergeCreators(ctx context.Context, into, from int64) error {
+	db.log.Printf("merging creator %v into %v\n", from, into)
+
+	if into == from {
+		err := fmt.Errorf("database.MergeCreators: %w: %v", errMergeSelf, into)
+		db.log.Println(err)
+
+		return err
+	}
+
+	tx, err := db.database.BeginTx(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("database.MergeCreators: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err = mergeCreators(ctx, tx, into, from)
+	if err != nil {
+		_ = tx.Rollback()
+
+		err = fmt.Errorf("database.MergeCreators: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = fmt.Errorf("database.MergeCreators: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// mergeCreators moves the aliases of creator from to into in tx, failing if
+// either creator is unknown.
+func mergeCreators(ctx context.Context, tx *sql.Tx, into, from int64) error {
+	result, err := tx.ExecContext(ctx, queries["mergeCreatorAliases"], into, from)
+	if err != nil {
+		return fmt.Errorf("database.mergeCreators: %w", err)
+	}
+
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("database.mergeCreators: %w", err)
+	}
+
+	if moved == 0 {
+		return fmt.Errorf("database.mergeCreators: %w: %v or %v", errUnknownCreator, into, from)
+	}
+
+	_, err = tx.ExecContext(ctx, queries["deleteCreator"], from)
+	if err != nil {
+		return fmt.Errorf("database.mergeCreators: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// creatorID returns the ID of the only indexed creator matching text.
+func creatorID(t *testing.T, db Database, text string) int64 {
+	t.Helper()
+
+	creators, err := db.Creators(context.Background(), text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(creators) != 1 {
+		t.Fatalf("Creators(%q) = %+v, want one", text, creators)
+	}
+
+	return creators[0].ID
+}
+
+func TestCreatorIndex(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+	ctx := context.Background()
+
+	// Batman #2 credits Tom King under a variant of his name that is filed
+	// with it, and Matt Banning under one that has to be merged by hand.
+	fs.editFixture(t, "books", batmanUUID, func(fixture map[string]any) {
+		book := fixture["values"].([]any)[1].(map[string]any)
+		book["authors"] = []any{map[string]any{"name": "tom-king-1", "display_name": "tom  king"}}
+		book["inkers"] = []any{map[string]any{"name": "m-banning", "display_name": "M. Banning"}}
+	})
+
+	err := db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	king, err := db.Creator(ctx, creatorID(t, db, "king"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "aliases", king.Aliases, []string{"tom-king", "tom-king-1"})
+
+	if king.DisplayName != "Tom King" || king.Issues != 2 || fmt.Sprint(king.Roles) != "[{author 2}]" {
+		t.Errorf("Creator = %+v, want Tom King, author of 2 issues", king)
+	}
+
+	creators, err := db.Creators(ctx, "banning")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(creators) != 2 {
+		t.Fatalf("Creators(banning) = %+v, want M. and Matt Banning", creators)
+	}
+
+	err = db.MergeCreators(ctx, creators[1].ID, creators[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	banning, err := db.Creator(ctx, creatorID(t, db, "banning"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if banning.DisplayName != "Matt Banning" || fmt.Sprint(banning.Roles) != "[{inker 2}]" {
+		t.Errorf("merged Creator = %+v, want Matt Banning, inker of 2 issues", banning)
+	}
+
+	issues, _, err := db.QueryIssues(ctx, ByCreator("M. Banning"), QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "issues by a merged alias", issueTitles(issues), []string{
+		"Batman (2016-) #2",
+		"Batman (2016-) #1",
+	})
+
+	finch := creatorID(t, db, "finch")
+
+	credits, err := db.CreatorCredits(ctx, finch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var groups []string
+	for _, g := range credits {
+		groups = append(groups, fmt.Sprintf("%v %v %v", g.Role, g.Series.Title, len(g.Issues)))
+	}
+
+	assertStrings(t, "credits", groups, []string{"coverArtist Batman (2016) 1", "penciller Batman (2016) 2"})
+
+	collaborators, err := db.TopCollaborators(ctx, finch, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(collaborators) != fmt.Sprintf("[{%v Matt Banning 2} {%v Tom King 2}]", banning.ID, king.ID) {
+		t.Errorf("TopCollaborators = %v, want Matt Banning and Tom King", collaborators)
+	}
+
+	err = db.MergeCreators(ctx, finch, finch)
+	if !errors.Is(err, errMergeSelf) {
+		t.Errorf("MergeCreators into itself returned %v, want %v", err, errMergeSelf)
+	}
+
+	err = db.MergeCreators(ctx, finch, -1)
+	if !errors.Is(err, errUnknownCreator) {
+		t.Errorf("MergeCreators of an unknown creator returned %v, want %v", err, errUnknownCreator)
+	}
+
+	// Refreshing again keeps the merge.
+	err = db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creatorID(t, db, "banning")
+}
+
+func TestCreatorBlankDisplayName(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	// Display names with nothing to group by are not grouped together.
+	fs.editFixture(t, "books", batmanUUID, func(fixture map[string]any) {
+		book := fixture["values"].([]any)[1].(map[string]any)
+		book["authors"] = []any{
+			map[string]any{"name": "anon-1", "display_name": ""},
+			map[string]any{"name": "anon-2", "display_name": "  "},
+			map[string]any{"name": "anon-3", "display_name": ". -"},
+		}
+	})
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "creators", queryStrings(t, db, `SELECT a.name || ' ' || a.nameKey || ' ' || c.displayName
+FROM creatorAlias a
+	JOIN creator c ON c.id = a.creatorID
+WHERE a.name LIKE 'anon%'
+ORDER BY a.name`), []string{"anon-1 anon1 anon-1", "anon-2 anon2 anon-2", "anon-3 anon3 anon-3"})
+}
@@ -6,8 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strings"
-	"time"
+	"path/filepath"
 
 	_ "github.com/mattn/go-sqlite3" // Required to use sqlite3 driver
 )
@@ -17,35 +16,66 @@ const (
 	sep     = string(os.PathSeparator)
 )
 
+var errNoClient = errors.New("no API client")
+
 type Database struct {
 	database *sql.DB
 	log      *log.Logger
+	api      *APIClient
+	// fts is whether SQLite was built with FTS5, so that the full-text index
+	// exists and is kept up to date.
+	fts bool
+	// pageSize is how many series new refreshes request at once.
+	pageSize int
 }
 
-func New() (Database, error) {
-	var dcuiDB Database
-
-	userHome, err := os.UserHomeDir()
+// New opens, creating if need be, the database and log given by cfg, and
+// talks to the DCUI API as cfg says.
+func New(cfg Config) (Database, error) {
+	cfg, err := cfg.Resolve()
 	if err != nil {
-		err = fmt.Errorf("database.New: %w", err)
+		return Database{}, fmt.Errorf("database.New: %w", err)
+	}
 
-		return dcuiDB, err
+	return open(cfg, cfg.apiClient())
+}
+
+// NewWithClient opens the database like New, but talks to the DCUI API
+// through a copy of the given client, ignoring the endpoints and limits in
+// cfg.
+func NewWithClient(cfg Config, client *APIClient) (Database, error) {
+	if client == nil {
+		return Database{}, fmt.Errorf("database.NewWithClient: %w", errNoClient)
 	}
 
-	logger, err := openLog(userHome)
+	cfg, err := cfg.Resolve()
 	if err != nil {
-		err = fmt.Errorf("database.New: %w", err)
+		return Database{}, fmt.Errorf("database.NewWithClient: %w", err)
+	}
+
+	return open(cfg, client)
+}
+
+// open opens the database and log given by the resolved cfg, talking to the
+// DCUI API through a copy of client that logs to the database's log.
+func open(cfg Config, client *APIClient) (Database, error) {
+	dcuiDB := Database{pageSize: cfg.PageSize}
+
+	logger, err := openLog(cfg.LogDir)
+	if err != nil {
+		err = fmt.Errorf("database.open: %w", err)
 
 		return dcuiDB, err
 	}
 
 	dcuiDB.log = logger
+	dcuiDB.api = client.withLog(log.New(logger.Writer(), "api: ", log.LstdFlags))
 
 	dcuiDB.log.Println("opening database")
 
-	dbase, err := openDB(userHome)
+	dbase, err := openDB(cfg.Database)
 	if err != nil {
-		err = fmt.Errorf("database.New: %w", err)
+		err = fmt.Errorf("database.open: %w", err)
 		dcuiDB.log.Println(err)
 
 		return dcuiDB, err
@@ -57,7 +87,15 @@ func New() (Database, error) {
 
 	err = dcuiDB.initialSetup()
 	if err != nil {
-		err = fmt.Errorf("database.New: %w", err)
+		err = fmt.Errorf("database.open: %w", err)
+		dcuiDB.log.Println(err)
+
+		return dcuiDB, err
+	}
+
+	dcuiDB.fts, err = dcuiDB.setupSearch()
+	if err != nil {
+		err = fmt.Errorf("database.open: %w", err)
 		dcuiDB.log.Println(err)
 
 		return dcuiDB, err
@@ -73,81 +111,30 @@ func (db Database) Close() {
 	defer db.database.Close()
 }
 
-func (db Database) RefreshDatabase() error {
-	db.log.Println("refreshing database")
+func (db Database) initialSetup() error {
+	db.log.Println("setting up database")
 
-	allSeries, err := db.getAllSeries()
+	err := db.migrate()
 	if err != nil {
-		err = fmt.Errorf("database.RefreshDatabase: %w", err)
+		err = fmt.Errorf("database.initialSetup: %w", err)
 		db.log.Println(err)
 
 		return err
 	}
 
-	for i, r := range allSeries {
-		t := r.Info.ComicSeries.TotalResultCount
-		for j, series := range r.Records.ComicSeries {
-			time.Sleep(apiDelay)
-
-			description, err := db.getSeriesDescription(series.UUID)
-			if err != nil {
-				err = fmt.Errorf("database.RefreshDatabase: %w", err)
-				db.log.Println(err)
-				if errors.Is(err, apiResponseError{}) {
-					db.log.Printf("skipping %v %v\n", series.UUID, series.Title)
-
-					continue
-				}
-
-				return err
-			}
-
-			series.description = description
-			c := i*100 + j + 1
-
-			db.log.Printf("inserting %v/%v\n", c, t)
-
-			err = db.insertSeries(series)
-			if err != nil {
-				err = fmt.Errorf("database.RefreshDatabase: %w", err)
-				db.log.Println(err)
-
-				return err
-			}
-		}
-	}
-
-	db.log.Println("done refreshing database")
+	db.log.Println("database setup complete")
 
 	return nil
 }
 
-func (db Database) initialSetup() error {
-	db.log.Println("setting up database")
-
-	rows, err := db.database.Query(queries["pingDatabase"])
-	if rows != nil {
-		defer rows.Close()
-	}
-
-	if err != nil || rows.Err() != nil {
-		_, err = db.database.Exec(queries["createDatabase"])
-		if err != nil {
-			err = fmt.Errorf("database.initialSetup: %w", err)
-			db.log.Println(err)
+func openLog(logPath string) (*log.Logger, error) {
+	err := os.MkdirAll(logPath, userRWX)
+	if err != nil {
+		err = fmt.Errorf("database.openLog: %w", err)
 
-			return err
-		}
+		return nil, err
 	}
 
-	db.log.Println("database setup complete")
-
-	return nil
-}
-
-func openLog(userHome string) (*log.Logger, error) {
-	logPath := userHome + sep + ".dcui" + sep + "logs"
-
 	logFile, err := os.OpenFile(logPath+sep+"dcui-scraper.log",
 		os.O_WRONLY|os.O_CREATE|os.O_APPEND, userRWX)
 	if err != nil {
@@ -161,8 +148,8 @@ func openLog(userHome string) (*log.Logger, error) {
 	return logger, nil
 }
 
-func openDB(userHome string) (*sql.DB, error) {
-	databasePath := userHome + sep + ".dcui"
+func openDB(databaseFile string) (*sql.DB, error) {
+	databasePath := filepath.Dir(databaseFile)
 
 	_, err := os.Stat(databasePath)
 	if err != nil {
@@ -180,9 +167,9 @@ func openDB(userHome string) (*sql.DB, error) {
 		}
 	}
 
-	databaseFile := databasePath + sep + "dcui.db"
-
-	dbase, err := sql.Open("sqlite3", databaseFile)
+	// WAL lets readers keep using the last committed snapshot while a refresh
+	// is writing, and the busy timeout stops them failing while it commits.
+	dbase, err := sql.Open("sqlite3", databaseFile+"?_journal_mode=WAL&_busy_timeout=5000")
 	if err != nil {
 		err = fmt.Errorf("database.openDB: %w", err)
 
@@ -191,75 +178,3 @@ func openDB(userHome string) (*sql.DB, error) {
 
 	return dbase, nil
 }
-
-func (db Database) insertSeries(series SearchResultRecordsComicseries) error {
-	db.log.Printf("upserting series %v\n", series.UUID)
-
-	updateThreshold := time.Now()
-	updateThreshold = updateThreshold.AddDate(-1, 0, 0)
-	updateThresholdInt := updateThreshold.Unix()
-
-	url := fmt.Sprintf("https://www.dcuniverseinfinite.com/comics/series/%v/%v", series.Slug, series.UUID)
-	value := fmt.Sprintf("('%v','%v','%v',%v,%v,%v,%v,'%v')",
-		sanitizeSQLString(series.UUID),
-		sanitizeSQLString(series.Title),
-		sanitizeSQLString(series.description),
-		series.BooksCount,
-		series.IssueCount,
-		series.VolumeCount,
-		series.OmnibusCount,
-		url)
-	qryUpsertSeries := fmt.Sprintf(templates["upsertSeries"], value, updateThresholdInt)
-
-	_, err := db.database.Exec(qryUpsertSeries)
-	if err != nil {
-		err = fmt.Errorf("database.insertSeries: %w", err)
-		db.log.Println(err)
-		db.log.Println(qryUpsertSeries)
-
-		return err
-	}
-
-	db.log.Println("upserting series genres")
-
-	for _, g := range series.Genres {
-		value = fmt.Sprintf("('%v','%v')", sanitizeSQLString(series.UUID), sanitizeSQLString(g))
-		qryUpsertSeriesGenre := fmt.Sprintf(templates["upsertSeriesGenre"], value)
-
-		_, err := db.database.Exec(qryUpsertSeriesGenre)
-		if err != nil {
-			err = fmt.Errorf("database.insertSeries: %w", err)
-			db.log.Println(err)
-			db.log.Println(qryUpsertSeriesGenre)
-
-			return err
-		}
-	}
-
-	db.log.Println("upserting series imprints")
-
-	for _, i := range series.Imprints {
-		value = fmt.Sprintf("('%v','%v')", sanitizeSQLString(series.UUID), sanitizeSQLString(i))
-		qryUpsertSeriesImprint := fmt.Sprintf(templates["upsertSeriesImprint"], value)
-
-		_, err := db.database.Exec(qryUpsertSeriesImprint)
-		if err != nil {
-			err = fmt.Errorf("database.insertSeries: %w", err)
-			db.log.Println(err)
-			db.log.Println(qryUpsertSeriesImprint)
-
-			return err
-		}
-	}
-
-	db.log.Println("series upsert complete")
-
-	return nil
-}
-
-func sanitizeSQLString(s string) string {
-	s = strings.ReplaceAll(s, "\r", " ")
-	s = strings.ReplaceAll(s, "\n", " ")
-
-	return strings.ReplaceAll(s, "'", "''")
-}
@@ -0,0 +1,117 @@
+package database
+
+import "time"
+
+// Series is a comic series as stored in the database.
+type Series struct {
+	UUID         string
+	Title        string
+	Description  string
+	BookCount    int
+	IssueCount   int
+	VolumeCount  int
+	OmnibusCount int
+	URL          string
+	// DateUpdated is when the series' details were last downloaded, or the
+	// zero time if they never have been.
+	DateUpdated time.Time
+	// DateRemoved is when the series was found to have been removed from
+	// DCUI, or the zero time if it is still there.
+	DateRemoved time.Time
+}
+
+// Issue is a single book as stored in the database, along with the series it
+// belongs to.
+type Issue struct {
+	UUID        string
+	Title       string
+	Description string
+	Publisher   string
+	Imprint     string
+	IssueNumber string
+	Pages       int
+	// PublicationDate is when the issue was published on DCUI, or the zero
+	// time if that is not known.
+	PublicationDate time.Time
+	URL             string
+	// DateRemoved is when the issue was found to have been removed from
+	// DCUI, or the zero time if it is still there.
+	DateRemoved time.Time
+	// Plans are the subscription plans the issue is exclusive to, such as
+	// "ultra". Issues in every plan, the base tier, have none.
+	Plans []string
+	// ToAdd is the day the issue is scheduled to be added to DCUI, or the
+	// zero time if it was already available when last refreshed.
+	ToAdd  time.Time
+	Series Series
+}
+
+// Kinds of SearchHit.
+const (
+	KindSeries = "series"
+	KindIssue  = "issue"
+)
+
+// SearchHit is a series or issue found by Search.
+type SearchHit struct {
+	// Kind is KindSeries or KindIssue.
+	Kind  string
+	UUID  string
+	Title string
+	URL   string
+	// SeriesUUID and SeriesTitle are those of an issue's series, and empty
+	// for a series.
+	SeriesUUID  string
+	SeriesTitle string
+	// Snippet is an excerpt of the title or description around the matches.
+	Snippet []SnippetPart
+	// Removed is whether the series or issue has been removed from DCUI.
+	Removed bool
+	// Rank orders hits best first, lower being better. It is only comparable
+	// between hits from the same search.
+	Rank float64
+}
+
+// SnippetPart is a run of snippet text, either matching the search or not.
+type SnippetPart struct {
+	Text  string
+	Match bool
+}
+
+// Kinds of Change.
+const (
+	ChangeNew                = "new"
+	ChangeRemoved            = "removed"
+	ChangeRestored           = "restored"
+	ChangeBookCountIncreased = "bookCountIncreased"
+	ChangeDescription        = "descriptionChanged"
+)
+
+// RefreshRun is a refresh of the database, finished or not.
+type RefreshRun struct {
+	ID          int64
+	Incremental bool
+	// Status is "running" for a refresh that is running or was interrupted,
+	// "complete" or "abandoned".
+	Status   string
+	Started  time.Time
+	Finished time.Time
+	// Changes is how many series changes the refresh recorded.
+	Changes int
+}
+
+// Change is a change to a series recorded by a refresh.
+type Change struct {
+	RunID      int64
+	Date       time.Time
+	Kind       string
+	SeriesUUID string
+	// SeriesTitle is the title of the series now, which for a removed
+	// series is the title it last had.
+	SeriesTitle string
+	// OldValue and NewValue are the book counts or descriptions before and
+	// after a change to them. NewValue is the title of a new or restored
+	// series, and OldValue that of a removed one.
+	OldValue string
+	NewValue string
+}
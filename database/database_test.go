@@ -0,0 +1,566 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	batmanUUID = "3f1c2a9e-0a1b-4c5d-8e9f-101112131415"
+	harleyUUID = "7a8b9c0d-1e2f-4a3b-9c4d-5e6f7a8b9c0d"
+	swampUUID  = "c0ffee00-1234-4567-89ab-cdef01234567"
+)
+
+// fixtureServer stands in for both the search engine and the comics API,
+// serving the recorded responses in testdata. Requests for which there is no
+// fixture get a 404, like a series that has been pulled from DCUI.
+type fixtureServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests map[string]int
+	broken   map[string]bool
+	// edited holds fixtures served in place of those in testdata.
+	edited map[string][]byte
+	// onRequest, if set, is called with the fixture name of every request.
+	onRequest func(fixture string)
+}
+
+func newFixtureServer(t *testing.T) *fixtureServer {
+	t.Helper()
+
+	fs := &fixtureServer{requests: map[string]int{}, broken: map[string]bool{}, edited: map[string][]byte{}}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /search.json", func(w http.ResponseWriter, r *http.Request) {
+		var body SearchBody
+
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		if body.EngineKey != "test-engine" {
+			http.Error(w, "bad engine key", http.StatusUnauthorized)
+
+			return
+		}
+
+		fs.serveFixture(w, "search", fmt.Sprintf("page%v", body.Page))
+	})
+
+	mux.HandleFunc("GET /series/{uuid}/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consumer-Key") != "test-consumer" {
+			http.Error(w, "bad consumer key", http.StatusUnauthorized)
+
+			return
+		}
+
+		fs.serveFixture(w, "series", r.PathValue("uuid"))
+	})
+
+	mux.HandleFunc("GET /series/{uuid}/books/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consumer-Key") != "test-consumer" {
+			http.Error(w, "bad consumer key", http.StatusUnauthorized)
+
+			return
+		}
+
+		fs.serveFixture(w, "books", r.PathValue("uuid"))
+	})
+
+	fs.Server = httptest.NewServer(mux)
+	t.Cleanup(fs.Close)
+
+	return fs
+}
+
+func (fs *fixtureServer) serveFixture(w http.ResponseWriter, kind, name string) {
+	fs.mu.Lock()
+	fs.requests[kind+"_"+name]++
+	broken := fs.broken[kind+"_"+name]
+	edited, isEdited := fs.edited[kind+"_"+name]
+	onRequest := fs.onRequest
+	fs.mu.Unlock()
+
+	if onRequest != nil {
+		onRequest(kind + "_" + name)
+	}
+
+	if broken {
+		_, _ = w.Write([]byte("{"))
+
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join("testdata", kind+"_"+name+".json"))
+	if isEdited {
+		data, err = edited, nil
+	}
+
+	if err != nil {
+		http.NotFound(w, nil)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// breakFixture makes the fixture be served as malformed JSON, which aborts a
+// refresh instead of skipping the series.
+func (fs *fixtureServer) breakFixture(kind, name string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.broken[kind+"_"+name] = true
+}
+
+// repairFixture undoes breakFixture.
+func (fs *fixtureServer) repairFixture(kind, name string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	delete(fs.broken, kind+"_"+name)
+}
+
+// editFixture serves the fixture as changed by edit, which is given it
+// decoded, from now on.
+func (fs *fixtureServer) editFixture(t *testing.T, kind, name string, edit func(fixture map[string]any)) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", kind+"_"+name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fixture map[string]any
+
+	err = json.Unmarshal(data, &fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edit(fixture)
+
+	data, err = json.Marshal(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.edited[kind+"_"+name] = data
+}
+
+func (fs *fixtureServer) requestCount(kind, name string) int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.requests[kind+"_"+name]
+}
+
+func (fs *fixtureServer) client() *APIClient {
+	client := NewAPIClient()
+	client.SearchURL = fs.URL + "/search.json"
+	client.ComicsURL = fs.URL
+	client.Transport = fs.Client().Transport
+	client.EngineKey = "test-engine"
+	client.ConsumerKey = "test-consumer"
+
+	return client
+}
+
+// newTestHome points the home directory at a temporary directory containing
+// an empty .dcui/logs and returns its path.
+func newTestHome(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	err := os.MkdirAll(filepath.Join(home, ".dcui", "logs"), userRWX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return home
+}
+
+// newTestDatabase opens a database under a temporary home directory that
+// talks to the given fixture server.
+func newTestDatabase(t *testing.T, fs *fixtureServer) Database {
+	t.Helper()
+
+	newTestHome(t)
+
+	db, err := NewWithClient(Config{}, fs.client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+func queryStrings(t *testing.T, db Database, query string, args ...any) []string {
+	t.Helper()
+
+	rows, err := db.database.Query(query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var values []string
+
+	for rows.Next() {
+		var v string
+
+		err = rows.Scan(&v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		values = append(values, v)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return values
+}
+
+func assertStrings(t *testing.T, name string, got, want []string) {
+	t.Helper()
+
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("%v = %q, want %q", name, got, want)
+	}
+}
+
+func TestNewWithClient(t *testing.T) {
+	newTestHome(t)
+
+	_, err := NewWithClient(Config{}, nil)
+	if !errors.Is(err, errNoClient) {
+		t.Errorf("NewWithClient without a client returned %v, want %v", err, errNoClient)
+	}
+
+	fs := newFixtureServer(t)
+	client := fs.client()
+	logger := client.log
+
+	db, err := NewWithClient(Config{}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if client.log != logger || db.api == client {
+		t.Error("NewWithClient changed the client it was given instead of a copy")
+	}
+}
+
+func TestRefreshDatabase(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both search pages are requested and every series from them is stored.
+	if n := fs.requestCount("search", "page1"); n != 1 {
+		t.Errorf("search page 1 requested %v times, want 1", n)
+	}
+
+	if n := fs.requestCount("search", "page2"); n != 1 {
+		t.Errorf("search page 2 requested %v times, want 1", n)
+	}
+
+	assertStrings(t, "series titles",
+		queryStrings(t, db, "SELECT title FROM series ORDER BY title"),
+		[]string{"Batman (2016)", "Harley Quinn's Greatest Hits", "Swamp Thing"})
+
+	assertStrings(t, "series counts",
+		queryStrings(t, db, `SELECT bookCount || '/' || issueCount || '/' || volumeCount || '/' || omnibusCount
+FROM series ORDER BY title`),
+		[]string{"2/2/0/0", "1/0/1/0", "1/1/0/0"})
+
+	assertStrings(t, "series url",
+		queryStrings(t, db, "SELECT url FROM series WHERE uuid = ?", batmanUUID),
+		[]string{"https://www.dcuniverseinfinite.com/comics/series/batman-2016/" + batmanUUID})
+
+	// Descriptions are stored byte-for-byte, including quotes and newlines.
+	assertStrings(t, "batman description",
+		queryStrings(t, db, "SELECT description FROM series WHERE uuid = ?", batmanUUID),
+		[]string{"Gotham City's protector returns.\nNo one is safe."})
+
+	assertStrings(t, "harley description",
+		queryStrings(t, db, "SELECT description FROM series WHERE uuid = ?", harleyUUID),
+		[]string{"The best of Harley's 'greatest' moments."})
+
+	assertStrings(t, "series genres",
+		queryStrings(t, db, `SELECT s.title || ':' || g.genre
+FROM seriesGenre g
+JOIN series s ON s.uuid = g.uuid
+ORDER BY s.title, g.genre`),
+		[]string{"Batman (2016):Action", "Batman (2016):Superhero", "Harley Quinn's Greatest Hits:Superhero",
+			"Swamp Thing:Horror"})
+
+	assertStrings(t, "series imprints",
+		queryStrings(t, db, `SELECT s.title || ':' || i.imprint
+FROM seriesImprint i
+JOIN series s ON s.uuid = i.uuid
+ORDER BY s.title, i.imprint`),
+		[]string{"Batman (2016):DC", "Harley Quinn's Greatest Hits:Black Label", "Harley Quinn's Greatest Hits:DC",
+			"Swamp Thing:Vertigo"})
+
+	// Swamp Thing's detail request 404s, so it is skipped and left flagged
+	// for the next refresh while the others are marked up to date.
+	assertStrings(t, "series needing update",
+		queryStrings(t, db, "SELECT uuid FROM series WHERE needUpdate = 1"),
+		[]string{swampUUID})
+
+	assertStrings(t, "series updated",
+		queryStrings(t, db, "SELECT uuid FROM series WHERE dateUpdated > 0 ORDER BY uuid"),
+		[]string{batmanUUID, harleyUUID})
+
+	assertStrings(t, "issues",
+		queryStrings(t, db, "SELECT title FROM issue ORDER BY title"),
+		[]string{"Batman (2016-) #1", "Batman (2016-) #2", "Harley Quinn's Greatest Hits"})
+
+	assertStrings(t, "issue tags",
+		queryStrings(t, db, "SELECT category || ':' || name FROM issueTag WHERE uuid = ? ORDER BY category, name",
+			"b0000001-0000-4000-8000-000000000001"),
+		[]string{":Bat-Family", "event:Rebirth"})
+
+	assertStrings(t, "issue creators",
+		queryStrings(t, db, "SELECT type || ':' || displayName FROM issueCreator WHERE uuid = ? ORDER BY type",
+			"b0000001-0000-4000-8000-000000000001"),
+		[]string{"author:Tom King", "colorist:Jordie Bellaire", "coverArtist:David Finch", "inker:Matt Banning",
+			"penciller:David Finch"})
+}
+
+func TestRefreshDatabaseIncremental(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{Incremental: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the series that was skipped the first time is still flagged, so it
+	// is the only one whose details are downloaded again.
+	if n := fs.requestCount("series", batmanUUID); n != 1 {
+		t.Errorf("batman details requested %v times, want 1", n)
+	}
+
+	if n := fs.requestCount("series", swampUUID); n != 2 {
+		t.Errorf("swamp thing details requested %v times, want 2", n)
+	}
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := fs.requestCount("series", batmanUUID); n != 2 {
+		t.Errorf("batman details requested %v times after full refresh, want 2", n)
+	}
+}
+
+func TestRefreshDatabaseWorkers(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{Workers: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "series updated",
+		queryStrings(t, db, "SELECT uuid FROM series WHERE needUpdate = 0 ORDER BY uuid"),
+		[]string{batmanUUID, harleyUUID})
+
+	assertStrings(t, "issues",
+		queryStrings(t, db, "SELECT title FROM issue ORDER BY title"),
+		[]string{"Batman (2016-) #1", "Batman (2016-) #2", "Harley Quinn's Greatest Hits"})
+
+	assertStrings(t, "runs", queryStrings(t, db, "SELECT status FROM refreshRun"), []string{"complete"})
+}
+
+func TestRefreshDatabaseFailure(t *testing.T) {
+	fs := newFixtureServer(t)
+	fs.breakFixture("books", harleyUUID)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err == nil {
+		t.Fatal("RefreshDatabase succeeded with malformed books response")
+	}
+
+	// Batches committed before the failure are kept. Series are updated in
+	// uuid order, so Batman is done before Harley Quinn fails.
+	assertStrings(t, "series titles",
+		queryStrings(t, db, "SELECT title FROM series ORDER BY title"),
+		[]string{"Batman (2016)", "Harley Quinn's Greatest Hits", "Swamp Thing"})
+
+	assertStrings(t, "series updated",
+		queryStrings(t, db, "SELECT uuid FROM series WHERE needUpdate = 0"),
+		[]string{batmanUUID})
+
+	assertStrings(t, "issues",
+		queryStrings(t, db, "SELECT title FROM issue ORDER BY title"),
+		[]string{"Batman (2016-) #1", "Batman (2016-) #2"})
+}
+
+func TestRefreshDatabaseAllOrNothing(t *testing.T) {
+	fs := newFixtureServer(t)
+	fs.breakFixture("books", harleyUUID)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{AllOrNothing: true})
+	if err == nil {
+		t.Fatal("RefreshDatabase succeeded with malformed books response")
+	}
+
+	assertStrings(t, "series titles", queryStrings(t, db, "SELECT title FROM series"), nil)
+	assertStrings(t, "issues", queryStrings(t, db, "SELECT title FROM issue"), nil)
+}
+
+func TestRefreshDatabaseResume(t *testing.T) {
+	fs := newFixtureServer(t)
+	fs.breakFixture("search", "page2")
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err == nil {
+		t.Fatal("RefreshDatabase succeeded with malformed search response")
+	}
+
+	// Reopen the database, as if the app had been closed after the failure.
+	db.Close()
+
+	db, err = NewWithClient(Config{}, fs.client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	fs.repairFixture("search", "page2")
+	fs.breakFixture("books", harleyUUID)
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{Incremental: true})
+	if err == nil {
+		t.Fatal("RefreshDatabase succeeded with malformed books response")
+	}
+
+	// The second attempt picks up at the page that failed.
+	if n := fs.requestCount("search", "page1"); n != 1 {
+		t.Errorf("search page 1 requested %v times, want 1", n)
+	}
+
+	fs.repairFixture("books", harleyUUID)
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{Incremental: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The third attempt picks up after Batman, the last series stored.
+	if n := fs.requestCount("search", "page2"); n != 2 {
+		t.Errorf("search page 2 requested %v times, want 2", n)
+	}
+
+	if n := fs.requestCount("series", batmanUUID); n != 1 {
+		t.Errorf("batman details requested %v times, want 1", n)
+	}
+
+	if n := fs.requestCount("series", harleyUUID); n != 2 {
+		t.Errorf("harley details requested %v times, want 2", n)
+	}
+
+	assertStrings(t, "runs",
+		queryStrings(t, db, "SELECT id || ':' || status || ':' || incremental FROM refreshRun"),
+		[]string{"1:complete:0"})
+
+	assertStrings(t, "series updated",
+		queryStrings(t, db, "SELECT uuid FROM series WHERE needUpdate = 0 ORDER BY uuid"),
+		[]string{batmanUUID, harleyUUID})
+
+	// Restarting ignores the checkpoint of an interrupted run.
+	fs.breakFixture("search", "page2")
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err == nil {
+		t.Fatal("RefreshDatabase succeeded with malformed search response")
+	}
+
+	fs.repairFixture("search", "page2")
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{Restart: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := fs.requestCount("search", "page1"); n != 3 {
+		t.Errorf("search page 1 requested %v times, want 3", n)
+	}
+
+	assertStrings(t, "runs",
+		queryStrings(t, db, "SELECT id || ':' || status FROM refreshRun ORDER BY id"),
+		[]string{"1:complete", "2:abandoned", "3:complete"})
+}
+
+func TestRefreshDatabaseCancel(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fs.onRequest = func(fixture string) {
+		if fixture == "series_"+harleyUUID {
+			cancel()
+		}
+	}
+
+	err := db.RefreshDatabase(ctx, RefreshOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("RefreshDatabase error = %v, want %v", err, context.Canceled)
+	}
+
+	// Batman was stored before the cancel and stays committed.
+	assertStrings(t, "series updated",
+		queryStrings(t, db, "SELECT uuid FROM series WHERE needUpdate = 0"),
+		[]string{batmanUUID})
+
+	assertStrings(t, "runs",
+		queryStrings(t, db, "SELECT status || ':' || lastSeriesUUID FROM refreshRun"),
+		[]string{"running:" + batmanUUID})
+}
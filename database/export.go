@@ -0,0 +1,350 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat is a file format that series and issues can be exported to.
+type ExportFormat string
+
+const (
+	// ExportCSV writes a header row, then a row per record. Lists are joined
+	// with "; ".
+	ExportCSV ExportFormat = "csv"
+	// ExportJSON writes an array of objects.
+	ExportJSON ExportFormat = "json"
+	// ExportJSONL writes an object per line.
+	ExportJSONL ExportFormat = "jsonl"
+)
+
+var errExportFormat = errors.New("unknown export format")
+
+// ParseExportFormat returns the format named by s, which may also be a file
+// extension such as ".csv".
+func ParseExportFormat(s string) (ExportFormat, error) {
+	f := ExportFormat(strings.ToLower(strings.TrimPrefix(s, ".")))
+
+	switch f {
+	case ExportCSV, ExportJSON, ExportJSONL:
+		return f, nil
+	default:
+		return "", fmt.Errorf("database.ParseExportFormat: %w: %q", errExportFormat, s)
+	}
+}
+
+// ExportedSeries is a series as written by ExportSeries.
+type ExportedSeries struct {
+	UUID         string   `json:"uuid"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	BookCount    int      `json:"bookCount"`
+	IssueCount   int      `json:"issueCount"`
+	VolumeCount  int      `json:"volumeCount"`
+	OmnibusCount int      `json:"omnibusCount"`
+	URL          string   `json:"url"`
+	DateUpdated  string   `json:"dateUpdated"`
+	DateRemoved  string   `json:"dateRemoved"`
+	Genres       []string `json:"genres"`
+	Imprints     []string `json:"imprints"`
+}
+
+func (ExportedSeries) csvHeader() []string {
+	return []string{"uuid", "title", "description", "bookCount", "issueCount", "volumeCount", "omnibusCount", "url",
+		"dateUpdated", "dateRemoved", "genres", "imprints"}
+}
+
+func (s ExportedSeries) csvRow() []string {
+	return []string{s.UUID, s.Title, s.Description, strconv.Itoa(s.BookCount), strconv.Itoa(s.IssueCount),
+		strconv.Itoa(s.VolumeCount), strconv.Itoa(s.OmnibusCount), s.URL, s.DateUpdated,
+		s.DateRemoved, strings.Join(s.Genres, "; "), strings.Join(s.Imprints, "; ")}
+}
+
+// ExportedIssue is an issue as written by ExportIssues.
+type ExportedIssue struct {
+	UUID            string           `json:"uuid"`
+	SeriesUUID      string           `json:"seriesUUID"`
+	SeriesTitle     string           `json:"seriesTitle"`
+	Title           string           `json:"title"`
+	Description     string           `json:"description"`
+	Publisher       string           `json:"publisher"`
+	Imprint         string           `json:"imprint"`
+	IssueNumber     string           `json:"issueNumber"`
+	Pages           int              `json:"pages"`
+	PublicationDate string           `json:"publicationDate"`
+	URL             string           `json:"url"`
+	DateRemoved     string           `json:"dateRemoved"`
+	Creators        []ExportedCredit `json:"creators"`
+	Tags            []ExportedTag    `json:"tags"`
+}
+
+// ExportedCredit is a creator's role on an exported issue.
+type ExportedCredit struct {
+	Role        string `json:"role"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// ExportedTag is a tag of an exported issue. Category is empty for tags
+// without one.
+type ExportedTag struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+}
+
+func (ExportedIssue) csvHeader() []string {
+	return []string{"uuid", "seriesUUID", "seriesTitle", "title", "description", "publisher", "imprint",
+		"issueNumber", "pages", "publicationDate", "url", "dateRemoved", "creators", "tags"}
+}
+
+func (i ExportedIssue) csvRow() []string {
+	creators := make([]string, len(i.Creators))
+	for j, c := range i.Creators {
+		creators[j] = c.DisplayName + " (" + c.Role + ")"
+	}
+
+	tags := make([]string, len(i.Tags))
+	for j, t := range i.Tags {
+		tags[j] = t.Name
+		if t.Category != "" {
+			tags[j] = t.Category + ": " + t.Name
+		}
+	}
+
+	return []string{i.UUID, i.SeriesUUID, i.SeriesTitle, i.Title, i.Description, i.Publisher, i.Imprint,
+		i.IssueNumber, strconv.Itoa(i.Pages), i.PublicationDate, i.URL, i.DateRemoved,
+		strings.Join(creators, "; "),
+		strings.Join(tags, "; ")}
+}
+
+// ExportSeries writes the series matching f, with their genres and imprints,
+// to w in title order, paged by opts, and returns how many were written.
+func (db Database) ExportSeries(ctx context.Context, w io.Writer, format ExportFormat, f Filter, opts QueryOptions,
+) (int, error) {
+	cond, args := opts.where(f, seriesScope)
+
+	db.log.Printf("exporting series where %v %v as %v\n", cond, args, format)
+
+	rows, err := db.database.QueryContext(ctx, fmt.Sprintf(queries["exportSeriesWhere"], cond),
+		append(args, opts.limit(), opts.Offset)...)
+	if err != nil {
+		err = fmt.Errorf("database.ExportSeries: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+	defer rows.Close()
+
+	n, err := export(rows, w, format, func(rows *sql.Rows) (ExportedSeries, error) {
+		var (
+			s                        ExportedSeries
+			dateUpdated, dateRemoved int64
+			genres, imprints         string
+		)
+
+		err := rows.Scan(&s.UUID, &s.Title, &s.Description, &s.BookCount, &s.IssueCount, &s.VolumeCount,
+			&s.OmnibusCount, &s.URL, &dateUpdated, &dateRemoved, &genres, &imprints)
+		if err != nil {
+			return s, err
+		}
+
+		s.DateUpdated = exportedTime(dateUpdated)
+		s.DateRemoved = exportedTime(dateRemoved)
+
+		err = json.Unmarshal([]byte(genres), &s.Genres)
+		if err != nil {
+			return s, err
+		}
+
+		err = json.Unmarshal([]byte(imprints), &s.Imprints)
+
+		return s, err
+	})
+	if err != nil {
+		err = fmt.Errorf("database.ExportSeries: %w", err)
+		db.log.Println(err)
+
+		return n, err
+	}
+
+	db.log.Printf("exported %v series\n", n)
+
+	return n, nil
+}
+
+// ExportIssues writes the issues matching f, with their creators and tags, to
+// w in series then issue number order, paged by opts, and returns how many
+// were written.
+func (db Database) ExportIssues(ctx context.Context, w io.Writer, format ExportFormat, f Filter, opts QueryOptions,
+) (int, error) {
+	cond, args := opts.where(f, issueScope)
+
+	db.log.Printf("exporting issues where %v %v as %v\n", cond, args, format)
+
+	rows, err := db.database.QueryContext(ctx, fmt.Sprintf(queries["exportIssuesWhere"], cond),
+		append(args, opts.limit(), opts.Offset)...)
+	if err != nil {
+		err = fmt.Errorf("database.ExportIssues: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+	defer rows.Close()
+
+	n, err := export(rows, w, format, func(rows *sql.Rows) (ExportedIssue, error) {
+		var (
+			i                            ExportedIssue
+			publicationDate, dateRemoved int64
+			creators, tags               string
+		)
+
+		err := rows.Scan(&i.UUID, &i.SeriesUUID, &i.SeriesTitle, &i.Title, &i.Description, &i.Publisher,
+			&i.Imprint, &i.IssueNumber, &i.Pages, &publicationDate, &i.URL, &dateRemoved, &creators, &tags)
+		if err != nil {
+			return i, err
+		}
+
+		if publicationDate > 0 {
+			i.PublicationDate = time.Unix(publicationDate, 0).UTC().Format(time.DateOnly)
+		}
+
+		i.DateRemoved = exportedTime(dateRemoved)
+
+		err = json.Unmarshal([]byte(creators), &i.Creators)
+		if err != nil {
+			return i, err
+		}
+
+		err = json.Unmarshal([]byte(tags), &i.Tags)
+
+		return i, err
+	})
+	if err != nil {
+		err = fmt.Errorf("database.ExportIssues: %w", err)
+		db.log.Println(err)
+
+		return n, err
+	}
+
+	db.log.Printf("exported %v issues\n", n)
+
+	return n, nil
+}
+
+// exportedTime formats a time stored as seconds since the epoch, where 0 means
+// none, as RFC 3339 in UTC, or as "" if there is none.
+func exportedTime(seconds int64) string {
+	if seconds == 0 {
+		return ""
+	}
+
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
+
+// exportRecord is a record that can be written as a CSV row as well as JSON.
+type exportRecord interface {
+	csvHeader() []string
+	csvRow() []string
+}
+
+// export writes each row, read by scan, to w in format as it is read, and
+// returns how many rows were written.
+func export[R exportRecord](rows *sql.Rows, w io.Writer, format ExportFormat, scan func(*sql.Rows) (R, error),
+) (int, error) {
+	var (
+		n         int
+		csvWriter *csv.Writer
+		zero      R
+	)
+
+	switch format {
+	case ExportCSV:
+		csvWriter = csv.NewWriter(w)
+
+		err := csvWriter.Write(zero.csvHeader())
+		if err != nil {
+			return 0, fmt.Errorf("database.export: %w", err)
+		}
+	case ExportJSON:
+		_, err := io.WriteString(w, "[")
+		if err != nil {
+			return 0, fmt.Errorf("database.export: %w", err)
+		}
+	case ExportJSONL:
+	default:
+		return 0, fmt.Errorf("database.export: %w: %q", errExportFormat, format)
+	}
+
+	for rows.Next() {
+		record, err := scan(rows)
+		if err != nil {
+			return n, fmt.Errorf("database.export: %w", err)
+		}
+
+		err = writeRecord(w, format, csvWriter, record, n == 0)
+		if err != nil {
+			return n, fmt.Errorf("database.export: %w", err)
+		}
+
+		n++
+	}
+
+	err := rows.Err()
+	if err != nil {
+		return n, fmt.Errorf("database.export: %w", err)
+	}
+
+	switch format {
+	case ExportCSV:
+		csvWriter.Flush()
+		err = csvWriter.Error()
+	case ExportJSON:
+		end := "\n]\n"
+		if n == 0 {
+			end = "]\n"
+		}
+
+		_, err = io.WriteString(w, end)
+	case ExportJSONL:
+	}
+
+	if err != nil {
+		return n, fmt.Errorf("database.export: %w", err)
+	}
+
+	return n, nil
+}
+
+// writeRecord writes a single record in format. first is whether it is the
+// first record written.
+func writeRecord(w io.Writer, format ExportFormat, csvWriter *csv.Writer, record exportRecord, first bool) error {
+	if format == ExportCSV {
+		return csvWriter.Write(record.csvRow())
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case format == ExportJSONL:
+		line = append(line, '\n')
+	case first:
+		line = append([]byte("\n  "), line...)
+	default:
+		line = append([]byte(",\n  "), line...)
+	}
+
+	_, err = w.Write(line)
+
+	return err
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExportSeries(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	n, err := db.ExportSeries(context.Background(), &buf, ExportCSV, ByGenre("Superhero"), QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 || len(records) != 3 {
+		t.Fatalf("exported %v series in %v CSV rows, want 2 series after a header", n, len(records))
+	}
+
+	assertStrings(t, "CSV header", records[0], ExportedSeries{}.csvHeader())
+	assertStrings(t, "Batman genres and imprints", records[1][10:], []string{"Action; Superhero", "DC"})
+	assertStrings(t, "Harley description", records[2][2:3], []string{"The best of Harley's 'greatest' moments."})
+
+	buf.Reset()
+
+	_, err = db.ExportSeries(context.Background(), &buf, ExportJSON, nil, QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var series []ExportedSeries
+
+	err = json.Unmarshal(buf.Bytes(), &series)
+	if err != nil {
+		t.Fatalf("%v in %s", err, buf.Bytes())
+	}
+
+	if len(series) != 3 || series[1].Title != "Harley Quinn's Greatest Hits" || series[1].VolumeCount != 1 ||
+		strings.Join(series[1].Imprints, "|") != "Black Label|DC" || series[1].DateUpdated == "" {
+		t.Errorf("JSON series = %+v", series)
+	}
+
+	buf.Reset()
+
+	n, err = db.ExportSeries(context.Background(), &buf, ExportJSON, ByGenre("Romance"), QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 || buf.String() != "[]\n" {
+		t.Errorf("empty JSON export = %q", buf.String())
+	}
+
+	_, err = db.ExportSeries(context.Background(), &buf, "xml", nil, QueryOptions{})
+	if err == nil {
+		t.Error("export as xml succeeded")
+	}
+}
+
+func TestExportIssues(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	n, err := db.ExportIssues(context.Background(), &buf, ExportJSONL, ByCreator("Tom King"), QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if n != 2 || len(lines) != 2 {
+		t.Fatalf("exported %v issues in %v lines, want 2", n, len(lines))
+	}
+
+	var issue ExportedIssue
+
+	err = json.Unmarshal([]byte(lines[0]), &issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ExportedCredit{
+		{"author", "tom-king", "Tom King"},
+		{"colorist", "jordie-bellaire", "Jordie Bellaire"},
+		{"coverArtist", "david-finch", "David Finch"},
+		{"inker", "matt-banning", "Matt Banning"},
+		{"penciller", "david-finch", "David Finch"},
+	}
+
+	if issue.Title != "Batman (2016-) #1" || issue.SeriesUUID != batmanUUID || issue.PublicationDate != "2016-06-15" ||
+		len(issue.Creators) != len(want) || len(issue.Tags) != 2 {
+		t.Fatalf("first JSONL issue = %+v", issue)
+	}
+
+	for i, c := range issue.Creators {
+		if c != want[i] {
+			t.Errorf("creator %v = %+v, want %+v", i, c, want[i])
+		}
+	}
+
+	buf.Reset()
+
+	_, err = db.ExportIssues(context.Background(), &buf, ExportCSV, ByTitle("#1"), QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("CSV export = %q, want one issue after a header", records)
+	}
+
+	assertStrings(t, "CSV tags", records[1][13:], []string{"Bat-Family; event: Rebirth"})
+}
+
+func TestParseExportFormat(t *testing.T) {
+	for _, s := range []string{"csv", ".JSON", "jsonl"} {
+		_, err := ParseExportFormat(s)
+		if err != nil {
+			t.Errorf("ParseExportFormat(%q) error = %v", s, err)
+		}
+	}
+
+	_, err := ParseExportFormat(".txt")
+	if err == nil {
+		t.Error("ParseExportFormat(.txt) succeeded")
+	}
+}
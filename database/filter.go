@@ -0,0 +1,503 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Filter selects series, or issues, from the database. Filters are made by
+// the functions below and combined with And and Or, then passed to
+// QuerySeries or QueryIssues, which compile them to SQL with every value
+// bound as a parameter. A nil Filter matches everything.
+//
+// Filters on series attributes select every issue of a matching series, and
+// filters on issue attributes select every series with a matching issue.
+type Filter interface {
+	// where returns the SQL condition selecting what the filter matches in
+	// scope sc, with a ? in it for each of args.
+	where(sc scope) (cond string, args []any)
+}
+
+// scope is what a filter selects: series, aliased s, or issues, aliased i,
+// joined to their series, aliased s.
+type scope int
+
+const (
+	seriesScope scope = iota
+	issueScope
+	// liveSeriesScope selects series by their issues still on DCUI only.
+	liveSeriesScope
+)
+
+// condition is a Filter on a single attribute. A series condition looking at
+// the series' issues names their alias in issues and has a %s at the end of
+// their WHERE, where the issues removed from DCUI are left out in
+// liveSeriesScope.
+type condition struct {
+	series string
+	issue  string
+	args   []any
+	issues string
+}
+
+func (c condition) where(sc scope) (string, []any) {
+	if sc == issueScope {
+		return c.issue, c.args
+	}
+
+	if c.issues == "" {
+		return c.series, c.args
+	}
+
+	var live string
+	if sc == liveSeriesScope {
+		live = "\n\t\tAND " + c.issues + ".dateRemoved = 0"
+	}
+
+	return fmt.Sprintf(c.series, live), c.args
+}
+
+// combination is a Filter combining others with AND or OR.
+type combination struct {
+	op      string
+	filters []Filter
+}
+
+func (c combination) where(sc scope) (string, []any) {
+	if len(c.filters) == 0 {
+		// An empty AND matches everything, an empty OR nothing.
+		if c.op == "AND" {
+			return "1", nil
+		}
+
+		return "0", nil
+	}
+
+	var (
+		conds []string
+		args  []any
+	)
+
+	for _, f := range c.filters {
+		cond, a := where(f, sc)
+		conds = append(conds, "("+cond+")")
+		args = append(args, a...)
+	}
+
+	return strings.Join(conds, " "+c.op+" "), args
+}
+
+// where compiles f, which may be nil, for scope sc.
+func where(f Filter, sc scope) (string, []any) {
+	if f == nil {
+		return "1", nil
+	}
+
+	return f.where(sc)
+}
+
+// And matches what every one of filters matches.
+func And(filters ...Filter) Filter {
+	return combination{op: "AND", filters: filters}
+}
+
+// Or matches what any one of filters matches.
+func Or(filters ...Filter) Filter {
+	return combination{op: "OR", filters: filters}
+}
+
+// ByGenre matches series in the genre, ignoring case.
+func ByGenre(genre string) Filter {
+	cond := `EXISTS (
+	SELECT 1
+	FROM seriesGenre g
+	WHERE g.uuid = s.uuid
+		AND g.genre = ? COLLATE NOCASE
+)`
+
+	return condition{series: cond, issue: cond, args: []any{genre}}
+}
+
+// ByImprint matches series published under the imprint, ignoring case.
+func ByImprint(imprint string) Filter {
+	cond := `EXISTS (
+	SELECT 1
+	FROM seriesImprint m
+	WHERE m.uuid = s.uuid
+		AND m.imprint = ? COLLATE NOCASE
+)`
+
+	return condition{series: cond, issue: cond, args: []any{imprint}}
+}
+
+// ByCreator matches issues the creator worked on in any role, given either
+// one of their names, such as "tom-king", or their display name, such as
+// "Tom King", ignoring case. Issues credited to the creator under another
+// name in the creator index match too.
+func ByCreator(name string) Filter {
+	named := `SELECT na.creatorID
+		FROM creatorAlias na
+			JOIN creator nc ON nc.id = na.creatorID
+		WHERE na.name = ? COLLATE NOCASE
+			OR nc.displayName = ? COLLATE NOCASE
+			OR na.nameKey = lower(replace(replace(replace(?, ' ', ''), '.', ''), '-', ''))`
+
+	c := creatorCondition(named)
+	c.args = []any{name, name, name}
+
+	return c
+}
+
+// ByCreatorID matches issues the creator with the ID in the creator index
+// worked on in any role.
+func ByCreatorID(id int64) Filter {
+	c := creatorCondition("?")
+	c.args = []any{id}
+
+	return c
+}
+
+// creatorCondition matches issues credited to the creators selected by ids,
+// a list of creator IDs or a query returning them.
+func creatorCondition(ids string) condition {
+	return condition{
+		series: `EXISTS (
+	SELECT 1
+	FROM issue ci
+		JOIN issueCreator c ON c.uuid = ci.uuid
+		JOIN creatorAlias a ON a.name = c.name
+	WHERE ci.seriesUUID = s.uuid%s
+		AND a.creatorID IN (` + ids + `)
+)`,
+		issue: `EXISTS (
+	SELECT 1
+	FROM issueCreator c
+		JOIN creatorAlias a ON a.name = c.name
+	WHERE c.uuid = i.uuid
+		AND a.creatorID IN (` + ids + `)
+)`,
+		issues: "ci",
+	}
+}
+
+// ByTag matches issues with the tag, such as "Rebirth", ignoring case.
+func ByTag(name string) Filter {
+	return condition{
+		series: `EXISTS (
+	SELECT 1
+	FROM issue ti
+		JOIN issueTag t ON t.uuid = ti.uuid
+	WHERE ti.seriesUUID = s.uuid%s
+		AND t.name = ? COLLATE NOCASE
+)`,
+		issue: `EXISTS (
+	SELECT 1
+	FROM issueTag t
+	WHERE t.uuid = i.uuid
+		AND t.name = ? COLLATE NOCASE
+)`,
+		args:   []any{name},
+		issues: "ti",
+	}
+}
+
+// ByTitle matches series, or issues, whose titles contain text, ignoring case.
+func ByTitle(text string) Filter {
+	return condition{
+		series: `s.title LIKE ? ESCAPE '\'`,
+		issue:  `i.title LIKE ? ESCAPE '\'`,
+		args:   []any{"%" + escapeLike(text) + "%"},
+	}
+}
+
+// MinIssues matches series with at least n issues.
+func MinIssues(n int) Filter {
+	cond := `s.issueCount >= ?`
+
+	return condition{series: cond, issue: cond, args: []any{n}}
+}
+
+// MaxIssues matches series with at most n issues.
+func MaxIssues(n int) Filter {
+	cond := `s.issueCount <= ?`
+
+	return condition{series: cond, issue: cond, args: []any{n}}
+}
+
+// PublishedBetween matches issues published on DCUI at or after from and
+// before to. A zero from or to leaves that end of the range open. Issues
+// without a known publication date never match.
+func PublishedBetween(from, to time.Time) Filter {
+	fromUnix, toUnix := unixRange(from, to)
+
+	return condition{
+		series: `EXISTS (
+	SELECT 1
+	FROM issue pi
+	WHERE pi.seriesUUID = s.uuid%s
+		AND pi.publicationDate > 0
+		AND pi.publicationDate >= ?
+		AND pi.publicationDate < ?
+)`,
+		issue: `i.publicationDate > 0
+	AND i.publicationDate >= ?
+	AND i.publicationDate < ?`,
+		args:   []any{fromUnix, toUnix},
+		issues: "pi",
+	}
+}
+
+// unixRange converts a range of times to seconds since the epoch, with a zero
+// from or to leaving that end of the range open.
+func unixRange(from, to time.Time) (int64, int64) {
+	var fromUnix, toUnix int64 = 0, math.MaxInt64
+	if !from.IsZero() {
+		fromUnix = from.Unix()
+	}
+
+	if !to.IsZero() {
+		toUnix = to.Unix()
+	}
+
+	return fromUnix, toUnix
+}
+
+// ExclusiveTo matches issues exclusive to the subscription plan, such as
+// "ultra", ignoring case.
+func ExclusiveTo(plan string) Filter {
+	return condition{
+		series: `EXISTS (
+	SELECT 1
+	FROM issue xi
+	WHERE xi.seriesUUID = s.uuid%s
+		AND (',' || xi.subscription || ',') LIKE ? ESCAPE '\'
+)`,
+		issue:  `(',' || i.subscription || ',') LIKE ? ESCAPE '\'`,
+		args:   []any{"%," + escapeLike(strings.TrimSpace(plan)) + ",%"},
+		issues: "xi",
+	}
+}
+
+// MovedToBaseTier matches issues, still in every plan, that were exclusive to
+// some until a refresh at or after from and before to. A zero from or to
+// leaves that end of the range open.
+func MovedToBaseTier(from, to time.Time) Filter {
+	fromUnix, toUnix := unixRange(from, to)
+
+	return condition{
+		series: `EXISTS (
+	SELECT 1
+	FROM issue mi
+		JOIN issuePlanChange pc ON pc.issueUUID = mi.uuid
+	WHERE mi.seriesUUID = s.uuid%s
+		AND mi.subscription = ''
+		AND pc.oldPlans <> ''
+		AND pc.newPlans = ''
+		AND pc.dateChanged >= ?
+		AND pc.dateChanged < ?
+)`,
+		issue: `i.subscription = ''
+	AND EXISTS (
+		SELECT 1
+		FROM issuePlanChange pc
+		WHERE pc.issueUUID = i.uuid
+			AND pc.oldPlans <> ''
+			AND pc.newPlans = ''
+			AND pc.dateChanged >= ?
+			AND pc.dateChanged < ?
+	)`,
+		args:   []any{fromUnix, toUnix},
+		issues: "mi",
+	}
+}
+
+// ScheduledToBeAdded matches issues that were scheduled to be added to DCUI
+// on or after the day of now, in UTC, when last refreshed.
+func ScheduledToBeAdded(now time.Time) Filter {
+	return condition{
+		series: `EXISTS (
+	SELECT 1
+	FROM issue ti
+	WHERE ti.seriesUUID = s.uuid%s
+		AND ti.toAdd >= ?
+)`,
+		issue:  `i.toAdd >= ?`,
+		args:   []any{now.UTC().Format(time.DateOnly)},
+		issues: "ti",
+	}
+}
+
+// QueryOptions page through the results of a query.
+type QueryOptions struct {
+	// Limit is the most results returned; zero or less returns them all.
+	Limit int
+	// Offset is how many results to skip.
+	Offset int
+	// IncludeRemoved includes series and issues that have been removed from
+	// DCUI, which are otherwise left out.
+	IncludeRemoved bool
+}
+
+// where compiles f for scope sc, leaving out what has been removed from DCUI,
+// including the issues series are selected by, unless opts include it.
+func (opts QueryOptions) where(f Filter, sc scope) (string, []any) {
+	if opts.IncludeRemoved {
+		return where(f, sc)
+	}
+
+	if sc == issueScope {
+		cond, args := where(f, sc)
+
+		return "i.dateRemoved = 0 AND (" + cond + ")", args
+	}
+
+	cond, args := where(f, liveSeriesScope)
+
+	return "s.dateRemoved = 0 AND (" + cond + ")", args
+}
+
+func (opts QueryOptions) limit() int {
+	if opts.Limit <= 0 {
+		return -1
+	}
+
+	return opts.Limit
+}
+
+// QuerySeries returns the series matching f in title order, paged by opts,
+// along with how many match in total.
+func (db Database) QuerySeries(ctx context.Context, f Filter, opts QueryOptions) ([]Series, int, error) {
+	cond, args := opts.where(f, seriesScope)
+
+	db.log.Printf("querying series where %v %v\n", cond, args)
+
+	var total int
+
+	err := db.database.QueryRowContext(ctx, fmt.Sprintf(queries["countSeriesWhere"], cond), args...).Scan(&total)
+	if err != nil {
+		err = fmt.Errorf("database.QuerySeries: %w", err)
+		db.log.Println(err)
+
+		return nil, 0, err
+	}
+
+	rows, err := db.database.QueryContext(ctx, fmt.Sprintf(queries["selectSeriesWhere"], cond),
+		append(args, opts.limit(), opts.Offset)...)
+	if err != nil {
+		err = fmt.Errorf("database.QuerySeries: %w", err)
+		db.log.Println(err)
+
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	series, err := scanSeries(rows)
+	if err != nil {
+		err = fmt.Errorf("database.QuerySeries: %w", err)
+		db.log.Println(err)
+
+		return nil, 0, err
+	}
+
+	db.log.Printf("%v of %v series match\n", len(series), total)
+
+	return series, total, nil
+}
+
+// QueryIssues returns the issues matching f, newest first, paged by opts,
+// along with how many match in total.
+func (db Database) QueryIssues(ctx context.Context, f Filter, opts QueryOptions) ([]Issue, int, error) {
+	cond, args := opts.where(f, issueScope)
+
+	db.log.Printf("querying issues where %v %v\n", cond, args)
+
+	var total int
+
+	err := db.database.QueryRowContext(ctx, fmt.Sprintf(queries["countIssuesWhere"], cond), args...).Scan(&total)
+	if err != nil {
+		err = fmt.Errorf("database.QueryIssues: %w", err)
+		db.log.Println(err)
+
+		return nil, 0, err
+	}
+
+	rows, err := db.database.QueryContext(ctx, fmt.Sprintf(queries["selectIssuesWhere"], cond),
+		append(args, opts.limit(), opts.Offset)...)
+	if err != nil {
+		err = fmt.Errorf("database.QueryIssues: %w", err)
+		db.log.Println(err)
+
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	issues, err := scanIssues(rows)
+	if err != nil {
+		err = fmt.Errorf("database.QueryIssues: %w", err)
+		db.log.Println(err)
+
+		return nil, 0, err
+	}
+
+	db.log.Printf("%v of %v issues match\n", len(issues), total)
+
+	return issues, total, nil
+}
+
+// Genres returns every genre a series is in, in order.
+func (db Database) Genres(ctx context.Context) ([]string, error) {
+	values, err := db.selectStrings(ctx, queries["selectGenres"])
+	if err != nil {
+		err = fmt.Errorf("database.Genres: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Imprints returns every imprint a series is published under, in order.
+func (db Database) Imprints(ctx context.Context) ([]string, error) {
+	values, err := db.selectStrings(ctx, queries["selectImprints"])
+	if err != nil {
+		err = fmt.Errorf("database.Imprints: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// selectStrings returns the single string column of every row of query.
+func (db Database) selectStrings(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := db.database.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("database.selectStrings: %w", err)
+	}
+	defer rows.Close()
+
+	var values []string
+
+	for rows.Next() {
+		var v string
+
+		err = rows.Scan(&v)
+		if err != nil {
+			return nil, fmt.Errorf("database.selectStrings: %w", err)
+		}
+
+		values = append(values, v)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("database.selectStrings: %w", err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQuerySeries(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		batman = "Batman (2016)"
+		harley = "Harley Quinn's Greatest Hits"
+		swamp  = "Swamp Thing"
+	)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"nil", nil, []string{batman, harley, swamp}},
+		{"genre", ByGenre("superhero"), []string{batman, harley}},
+		{"imprint", ByImprint("Black Label"), []string{harley}},
+		{"creator name", ByCreator("tom-king"), []string{batman}},
+		{"creator display name", ByCreator("paul dini"), []string{harley}},
+		{"tag", ByTag("rebirth"), []string{batman}},
+		{"title", ByTitle("thing"), []string{swamp}},
+		{"min issues", MinIssues(1), []string{batman, swamp}},
+		{"max issues", MaxIssues(1), []string{harley, swamp}},
+		{"published", PublishedBetween(time.Date(2016, 8, 1, 0, 0, 0, 0, time.UTC), time.Time{}), []string{harley}},
+		{"and", And(ByGenre("Superhero"), ByImprint("DC"), MinIssues(1)), []string{batman}},
+		{"or", Or(ByGenre("Horror"), ByCreator("Paul Dini")), []string{harley, swamp}},
+		{"nested", Or(And(ByGenre("Superhero"), MaxIssues(0)), ByTitle("swamp")), []string{harley, swamp}},
+		{"empty and", And(), []string{batman, harley, swamp}},
+		{"empty or", Or(), nil},
+		{"injection", ByGenre("x' OR 1 = 1 --"), nil},
+	}
+
+	for _, tt := range tests {
+		series, total, err := db.QuerySeries(context.Background(), tt.filter, QueryOptions{})
+		if err != nil {
+			t.Fatalf("%v: %v", tt.name, err)
+		}
+
+		var got []string
+		for _, s := range series {
+			got = append(got, s.Title)
+		}
+
+		assertStrings(t, tt.name, got, tt.want)
+
+		if total != len(tt.want) {
+			t.Errorf("%v: total = %v, want %v", tt.name, total, len(tt.want))
+		}
+	}
+
+	series, total, err := db.QuerySeries(context.Background(), nil, QueryOptions{Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(series) != 1 || series[0].Title != harley || total != 3 {
+		t.Errorf("second page of one = %+v of %v, want %v of 3", series, total, harley)
+	}
+}
+
+func TestQueryIssues(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"nil", nil, []string{"Harley Quinn's Greatest Hits", "Batman (2016-) #2", "Batman (2016-) #1"}},
+		{"creator", ByCreator("Matt Banning"), []string{"Batman (2016-) #1"}},
+		{"tag or genre", Or(ByTag("Bat-Family"), ByImprint("Black Label")),
+			[]string{"Harley Quinn's Greatest Hits", "Batman (2016-) #1"}},
+		{"series genre and issue title", And(ByGenre("Action"), ByTitle("#2")), []string{"Batman (2016-) #2"}},
+		{"published", PublishedBetween(time.Time{}, time.Date(2016, 7, 1, 0, 0, 0, 0, time.UTC)),
+			[]string{"Batman (2016-) #1"}},
+	}
+
+	for _, tt := range tests {
+		issues, total, err := db.QueryIssues(context.Background(), tt.filter, QueryOptions{})
+		if err != nil {
+			t.Fatalf("%v: %v", tt.name, err)
+		}
+
+		var got []string
+		for _, i := range issues {
+			got = append(got, i.Title)
+		}
+
+		assertStrings(t, tt.name, got, tt.want)
+
+		if total != len(tt.want) {
+			t.Errorf("%v: total = %v, want %v", tt.name, total, len(tt.want))
+		}
+	}
+
+	genres, err := db.Genres(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "Genres", genres, []string{"Action", "Horror", "Superhero"})
+
+	imprints, err := db.Imprints(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "Imprints", imprints, []string{"Black Label", "DC", "Vertigo"})
+}
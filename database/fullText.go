@@ -0,0 +1,385 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// snippetMatchStart and snippetMatchEnd surround the matches in snippets
+	// returned by searchFullText.
+	snippetMatchStart = "\x02"
+	snippetMatchEnd   = "\x03"
+	// snippetContext is roughly how many characters of context are kept
+	// either side of the first match in a LIKE search snippet.
+	snippetContext = 60
+)
+
+// setupSearch creates the full-text index if SQLite was built with FTS5,
+// rebuilding it if it has fallen behind the catalog, and reports whether it
+// is available. go-sqlite3 only includes FTS5 when built with -tags
+// sqlite_fts5, as the Makefile does. Without it, Search falls back to LIKE,
+// which is only meant for builds that cannot have FTS5.
+func (db Database) setupSearch() (bool, error) {
+	db.log.Println("setting up full-text search")
+
+	var enabled bool
+
+	err := db.database.QueryRow(queries["selectFTS5Enabled"]).Scan(&enabled)
+	if err != nil {
+		err = fmt.Errorf("database.setupSearch: %w", err)
+		db.log.Println(err)
+
+		return false, err
+	}
+
+	if !enabled {
+		db.log.Println("warning: SQLite was built without FTS5 (build with -tags sqlite_fts5); " +
+			"falling back to LIKE search, which ranks hits less well")
+
+		return false, nil
+	}
+
+	tx, err := db.database.Begin()
+	if err != nil {
+		err = fmt.Errorf("database.setupSearch: %w", err)
+		db.log.Println(err)
+
+		return false, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec(queries["createSearchIndex"])
+	if err != nil {
+		err = fmt.Errorf("database.setupSearch: %w", err)
+		db.log.Println(err)
+
+		return false, err
+	}
+
+	var stale bool
+
+	err = tx.QueryRow(queries["selectSearchIndexStale"]).Scan(&stale)
+	if err != nil {
+		err = fmt.Errorf("database.setupSearch: %w", err)
+		db.log.Println(err)
+
+		return false, err
+	}
+
+	if stale {
+		db.log.Println("rebuilding full-text index")
+
+		_, err = tx.Exec(queries["rebuildSearchIndex"])
+		if err != nil {
+			err = fmt.Errorf("database.setupSearch: %w", err)
+			db.log.Println(err)
+
+			return false, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = fmt.Errorf("database.setupSearch: %w", err)
+		db.log.Println(err)
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// FullTextSearch reports whether Search uses the full-text index, rather than
+// the LIKE fallback of builds without FTS5.
+func (db Database) FullTextSearch() bool {
+	return db.fts
+}
+
+// indexDocument brings the full-text index entry of a stored series or issue
+// up to date. It does nothing if the index is unavailable.
+func (db Database) indexDocument(stmts preparedStatements, kind, uuid string) error {
+	if !db.fts {
+		return nil
+	}
+
+	_, err := stmts["insertSearchDocument"].Exec(kind, uuid)
+	if err != nil {
+		err = fmt.Errorf("database.indexDocument: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	_, err = stmts["deleteSearchEntry"].Exec(kind, uuid)
+	if err != nil {
+		err = fmt.Errorf("database.indexDocument: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	index := "indexSeries"
+	if kind == KindIssue {
+		index = "indexIssue"
+	}
+
+	_, err = stmts[index].Exec(uuid)
+	if err != nil {
+		err = fmt.Errorf("database.indexDocument: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// Search returns up to limit series and issues whose titles or descriptions
+// contain every word of query, best match first. Each word also matches
+// words it is the start of. A limit of zero or less returns every match.
+// Series and issues removed from DCUI are only included if includeRemoved is
+// true.
+func (db Database) Search(ctx context.Context, query string, limit int, includeRemoved bool) ([]SearchHit, error) {
+	db.log.Printf("searching for %q\n", query)
+
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	if limit <= 0 {
+		limit = -1
+	}
+
+	var (
+		hits []SearchHit
+		err  error
+	)
+
+	if db.fts {
+		hits, err = db.searchFullText(ctx, terms, limit, includeRemoved)
+	} else {
+		hits, err = db.searchLike(ctx, terms, limit, includeRemoved)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("database.Search: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	db.log.Printf("%v hits for %q\n", len(hits), query)
+
+	return hits, nil
+}
+
+func (db Database) searchFullText(ctx context.Context, terms []string, limit int, includeRemoved bool,
+) ([]SearchHit, error) {
+	// Each term is quoted so that FTS5 query syntax in it is taken literally,
+	// and made a prefix query.
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(t, `"`, `""`) + `"*`
+	}
+
+	rows, err := db.database.QueryContext(ctx, queries["searchFullText"], strings.Join(quoted, " "), includeRemoved,
+		limit)
+	if err != nil {
+		return nil, fmt.Errorf("database.searchFullText: %w", err)
+	}
+	defer rows.Close()
+
+	hits, err := scanSearchHits(rows, markedSnippet)
+	if err != nil {
+		return nil, fmt.Errorf("database.searchFullText: %w", err)
+	}
+
+	return hits, nil
+}
+
+// searchLike is the fallback for builds without FTS5. Hits matching in their
+// titles rank above the rest, and matches are found in snippets with a
+// regular expression rather than by SQLite.
+func (db Database) searchLike(ctx context.Context, terms []string, limit int, includeRemoved bool,
+) ([]SearchHit, error) {
+	var (
+		titleConds, seriesConds, issueConds []string
+		titleArgs, matchArgs                []any
+	)
+
+	for _, t := range terms {
+		pattern := "%" + escapeLike(t) + "%"
+
+		titleConds = append(titleConds, `title LIKE ? ESCAPE '\'`)
+		seriesConds = append(seriesConds, `(title LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`)
+		issueConds = append(issueConds, `(i.title LIKE ? ESCAPE '\' OR i.description LIKE ? ESCAPE '\')`)
+		titleArgs = append(titleArgs, pattern)
+		matchArgs = append(matchArgs, pattern, pattern)
+	}
+
+	query := fmt.Sprintf(queries["searchLike"],
+		strings.Join(titleConds, " AND "), strings.Join(seriesConds, " AND "), strings.Join(issueConds, " AND "))
+
+	args := slices.Concat(titleArgs, []any{includeRemoved}, matchArgs, []any{includeRemoved}, matchArgs, []any{limit})
+
+	rows, err := db.database.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("database.searchLike: %w", err)
+	}
+	defer rows.Close()
+
+	match := termPattern(terms)
+
+	hits, err := scanSearchHits(rows, func(description string) []SnippetPart {
+		return likeSnippet(description, match)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("database.searchLike: %w", err)
+	}
+
+	// If the description has no match, only the title did.
+	for i, h := range hits {
+		if !slices.ContainsFunc(h.Snippet, func(p SnippetPart) bool { return p.Match }) {
+			hits[i].Snippet = likeSnippet(h.Title, match)
+		}
+	}
+
+	return hits, nil
+}
+
+// scanSearchHits reads every row of a search query, turning the snippet
+// column into parts with snippet.
+func scanSearchHits(rows *sql.Rows, snippet func(string) []SnippetPart) ([]SearchHit, error) {
+	var hits []SearchHit
+
+	for rows.Next() {
+		var (
+			h    SearchHit
+			text string
+		)
+
+		err := rows.Scan(&h.Kind, &h.UUID, &h.Title, &h.URL, &h.SeriesUUID, &h.SeriesTitle, &text, &h.Removed,
+			&h.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("database.scanSearchHits: %w", err)
+		}
+
+		h.Snippet = snippet(text)
+
+		hits = append(hits, h)
+	}
+
+	err := rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("database.scanSearchHits: %w", err)
+	}
+
+	return hits, nil
+}
+
+// markedSnippet splits a snippet whose matches are surrounded by
+// snippetMatchStart and snippetMatchEnd into parts.
+func markedSnippet(snippet string) []SnippetPart {
+	var parts []SnippetPart
+
+	snippet = strings.ReplaceAll(snippet, "\n", " ")
+
+	for snippet != "" {
+		before, rest, found := strings.Cut(snippet, snippetMatchStart)
+		if before != "" {
+			parts = append(parts, SnippetPart{Text: before})
+		}
+
+		if !found {
+			break
+		}
+
+		match, after, _ := strings.Cut(rest, snippetMatchEnd)
+		if match != "" {
+			parts = append(parts, SnippetPart{Text: match, Match: true})
+		}
+
+		snippet = after
+	}
+
+	return parts
+}
+
+// termPattern matches any of terms, ignoring case.
+func termPattern(terms []string) *regexp.Regexp {
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// likeSnippet cuts an excerpt of text around the first match of match and
+// splits it into parts. It returns the whole of text as a single part if
+// nothing matches.
+func likeSnippet(text string, match *regexp.Regexp) []SnippetPart {
+	text = strings.ReplaceAll(text, "\n", " ")
+
+	matches := match.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return []SnippetPart{{Text: text}}
+	}
+
+	start := max(matches[0][0]-snippetContext, 0)
+	end := min(matches[0][1]+2*snippetContext, len(text))
+
+	// Move the ends of the excerpt back to the start of a character.
+	for start > 0 && !isRuneStart(text[start]) {
+		start--
+	}
+
+	for end < len(text) && !isRuneStart(text[end]) {
+		end--
+	}
+
+	var parts []SnippetPart
+
+	if start > 0 {
+		parts = append(parts, SnippetPart{Text: "…"})
+	}
+
+	pos := start
+
+	for _, m := range matches {
+		if m[0] >= end {
+			break
+		}
+
+		if m[0] > pos {
+			parts = append(parts, SnippetPart{Text: text[pos:m[0]]})
+		}
+
+		mEnd := min(m[1], end)
+		parts = append(parts, SnippetPart{Text: text[m[0]:mEnd], Match: true})
+		pos = mEnd
+	}
+
+	if pos < end {
+		parts = append(parts, SnippetPart{Text: text[pos:end]})
+	}
+
+	if end < len(text) {
+		parts = append(parts, SnippetPart{Text: "…"})
+	}
+
+	return parts
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80 //nolint:mnd
+}
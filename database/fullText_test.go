@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// requireFTS5 fails the test if db has no full-text index, so that a build
+// without FTS5 cannot pass having only tested the LIKE fallback.
+func requireFTS5(t *testing.T, db Database) {
+	t.Helper()
+
+	if !db.FullTextSearch() {
+		t.Fatal("SQLite was built without FTS5, so only the LIKE fallback can be tested; " +
+			"run the tests with -tags sqlite_fts5, as make test does")
+	}
+}
+
+// searchTitles runs Search and returns the kind and title of each hit, sorted,
+// since the full-text index and LIKE rank hits differently. It checks that
+// the hits are in rank order.
+func searchTitles(t *testing.T, db Database, query string) []string {
+	t.Helper()
+
+	hits, err := db.Search(context.Background(), query, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var titles []string
+	for i, h := range hits {
+		if i > 0 && h.Rank < hits[i-1].Rank {
+			t.Errorf("Search(%v) hit %v ranked %v after %v", query, i, h.Rank, hits[i-1].Rank)
+		}
+
+		titles = append(titles, h.Kind+":"+h.Title)
+	}
+
+	slices.Sort(titles)
+
+	return titles
+}
+
+// snippetText joins the parts of a snippet, bracketing the matches.
+func snippetText(parts []SnippetPart) string {
+	var b strings.Builder
+
+	for _, p := range parts {
+		if p.Match {
+			b.WriteString("[" + p.Text + "]")
+		} else {
+			b.WriteString(p.Text)
+		}
+	}
+
+	return b.String()
+}
+
+func TestSearch(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requireFTS5(t, db)
+
+	// Run the same searches through the full-text index and the LIKE
+	// fallback.
+	like := db
+	like.fts = false
+
+	for name, db := range map[string]Database{"fts5": db, "like": like} {
+		t.Run(name, func(t *testing.T) {
+			assertStrings(t, "Search(gotham)", searchTitles(t, db, "gotham"),
+				[]string{"issue:Batman (2016-) #1", "issue:Batman (2016-) #2", "series:Batman (2016)"})
+			assertStrings(t, "Search(harley)", searchTitles(t, db, "harley"),
+				[]string{"issue:Harley Quinn's Greatest Hits", "series:Harley Quinn's Greatest Hits"})
+			assertStrings(t, "Search(gotham two)", searchTitles(t, db, "gotham two"),
+				[]string{"issue:Batman (2016-) #2"})
+			assertStrings(t, "Search(protect)", searchTitles(t, db, "protect"),
+				[]string{"series:Batman (2016)"})
+			assertStrings(t, "Search(\"joker\")", searchTitles(t, db, `"joker" OR`), nil)
+			assertStrings(t, "Search()", searchTitles(t, db, " "), nil)
+
+			hits, err := db.Search(context.Background(), "protector", 1, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(hits) != 1 || hits[0].URL == "" || hits[0].SeriesUUID != "" {
+				t.Fatalf("Search(protector) = %+v, want the Batman series", hits)
+			}
+
+			if got := snippetText(hits[0].Snippet); !strings.Contains(got, "[protector]") {
+				t.Errorf("Search(protector) snippet = %q, want match highlighted", got)
+			}
+
+			hits, err = db.Search(context.Background(), "two", 0, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(hits) != 1 || hits[0].SeriesUUID != batmanUUID || hits[0].SeriesTitle != "Batman (2016)" {
+				t.Errorf("Search(two) = %+v, want Batman #2 with its series", hits)
+			}
+		})
+	}
+}
+
+func TestLikeSnippet(t *testing.T) {
+	text := strings.Repeat("a ", 50) + "Gotham City is protected by gotham's Batman." + strings.Repeat(" z", 100)
+
+	got := snippetText(likeSnippet(text, termPattern([]string{"gotham"})))
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") ||
+		!strings.Contains(got, "[Gotham] City is protected by [gotham]'s") {
+		t.Errorf("likeSnippet = %q", got)
+	}
+
+	got = snippetText(likeSnippet("No match here", termPattern([]string{"gotham"})))
+	if got != "No match here" {
+		t.Errorf("likeSnippet without match = %q", got)
+	}
+}
+
+func TestSearchIndexRebuild(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	requireFTS5(t, db)
+
+	// A refresh by a build without FTS5 leaves the index behind.
+	noFTS := db
+	noFTS.fts = false
+
+	err := noFTS.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "Search(gotham) before rebuild", searchTitles(t, db, "gotham"), nil)
+
+	reopened, err := NewWithClient(Config{}, fs.client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	assertStrings(t, "Search(gotham) after rebuild", searchTitles(t, reopened, "gotham"),
+		[]string{"issue:Batman (2016-) #1", "issue:Batman (2016-) #2", "series:Batman (2016)"})
+}
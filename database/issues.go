@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// IssuesPublished returns the issues, newest first, published on DCUI at or
+// after from and before to. A zero from or to leaves that end of the range
+// open. Issues without a known publication date are never returned, and those
+// removed from DCUI only if includeRemoved is true.
+func (db Database) IssuesPublished(ctx context.Context, from, to time.Time, includeRemoved bool) ([]Issue, error) {
+	db.log.Printf("getting issues published from %v to %v\n", from, to)
+
+	issues, _, err := db.QueryIssues(ctx, PublishedBetween(from, to), QueryOptions{IncludeRemoved: includeRemoved})
+	if err != nil {
+		err = fmt.Errorf("database.IssuesPublished: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// scanIssues reads every row of a query selecting the columns of Issue in
+// order followed by those of its Series.
+func scanIssues(rows *sql.Rows) ([]Issue, error) {
+	var issues []Issue
+
+	for rows.Next() {
+		i, err := scanIssue(rows)
+		if err != nil {
+			return nil, fmt.Errorf("database.scanIssues: %w", err)
+		}
+
+		issues = append(issues, i)
+	}
+
+	err := rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("database.scanIssues: %w", err)
+	}
+
+	return issues, nil
+}
+
+// scanIssue reads the current row of a query selecting the columns of Issue
+// in order, followed by those of its Series and then any columns read into
+// extra.
+func scanIssue(rows *sql.Rows, extra ...any) (Issue, error) {
+	var (
+		i                                    Issue
+		publicationDate, dateRemoved         int64
+		plans, toAdd                         string
+		seriesDateUpdated, seriesDateRemoved int64
+	)
+
+	dest := append([]any{&i.UUID, &i.Title, &i.Description, &i.Publisher, &i.Imprint, &i.IssueNumber, &i.Pages,
+		&publicationDate, &i.URL, &dateRemoved, &plans, &toAdd, &i.Series.UUID, &i.Series.Title, &i.Series.Description,
+		&i.Series.BookCount, &i.Series.IssueCount, &i.Series.VolumeCount, &i.Series.OmnibusCount,
+		&i.Series.URL, &seriesDateUpdated, &seriesDateRemoved}, extra...)
+
+	err := rows.Scan(dest...)
+	if err != nil {
+		return i, fmt.Errorf("database.scanIssue: %w", err)
+	}
+
+	i.PublicationDate = unixTime(publicationDate)
+	i.DateRemoved = unixTime(dateRemoved)
+	i.Plans = splitPlans(plans)
+	i.ToAdd, _ = time.Parse(time.DateOnly, toAdd)
+	i.Series.DateUpdated = unixTime(seriesDateUpdated)
+	i.Series.DateRemoved = unixTime(seriesDateRemoved)
+
+	return i, nil
+}
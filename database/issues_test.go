@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIssuesPublished(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return d
+	}
+
+	tests := []struct {
+		from, to time.Time
+		want     []string
+	}{
+		{time.Time{}, time.Time{},
+			[]string{"Harley Quinn's Greatest Hits", "Batman (2016-) #2", "Batman (2016-) #1"}},
+		{date("2016-06-15"), date("2016-07-06"), []string{"Batman (2016-) #1"}},
+		{date("2016-07-01"), time.Time{}, []string{"Harley Quinn's Greatest Hits", "Batman (2016-) #2"}},
+		{time.Time{}, date("2016-06-01"), nil},
+	}
+
+	for _, tt := range tests {
+		issues, err := db.IssuesPublished(context.Background(), tt.from, tt.to, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, i := range issues {
+			got = append(got, i.Title)
+		}
+
+		assertStrings(t, "IssuesPublished("+tt.from.String()+", "+tt.to.String()+")", got, tt.want)
+	}
+
+	issues, err := db.IssuesPublished(context.Background(), date("2016-08-10"), time.Time{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 1 || issues[0].Series.UUID != harleyUUID || issues[0].Pages != 200 ||
+		!issues[0].PublicationDate.Equal(date("2016-08-10")) {
+		t.Errorf("IssuesPublished(2016-08-10, ) = %+v, want Harley Quinn's Greatest Hits", issues)
+	}
+}
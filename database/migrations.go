@@ -0,0 +1,450 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration upgrades the schema to version from the version before it.
+// Migrations must never be edited once released; schema changes are made by
+// appending a new migration to migrations.
+type migration struct {
+	version     int
+	description string
+	query       string
+}
+
+// migrations are applied in order by migrate. Version 1 uses IF NOT EXISTS so
+// that databases created before schema versioning are adopted as they are.
+//
+//nolint:gochecknoglobals
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create catalog tables",
+		query: `-- Create series table
+CREATE TABLE IF NOT EXISTS series (
+	uuid         TEXT NOT NULL PRIMARY KEY,
+	title        TEXT NOT NULL,
+	description  TEXT NOT NULL,
+	bookCount    INT NOT NULL,
+	issueCount   INT NOT NULL,
+	volumeCount  INT NOT NULL,
+	omnibusCount INT NOT NULL,
+	url          TEXT NOT NULL,
+	dateUpdated  INT NOT NULL DEFAULT 0,
+	needUpdate   INT NOT NULL DEFAULT 1
+);
+
+-- Create issue table
+CREATE TABLE IF NOT EXISTS issue (
+	uuid            TEXT NOT NULL PRIMARY KEY,
+	seriesUUID      INT NOT NULL,
+	title           TEXT NOT NULL,
+	description     TEXT NOT NULL,
+	publisher       TEXT NOT NULL,
+	imprint         TEXT NOT NULL,
+	issueNumber     TEXT NOT NULL,
+	pages           INT NOT NULL,
+	publicationDate INT NOT NULL,
+	url             TEXT NOT NULL,
+	subscription    TEXT NOT NULL,
+	toAdd           TEXT,
+	FOREIGN KEY (seriesUUID) REFERENCES series(uuid) ON DELETE CASCADE
+);
+
+-- Create series genre table
+CREATE TABLE IF NOT EXISTS seriesGenre (
+	uuid  INT NOT NULL,
+	genre TEXT NOT NULL,
+	PRIMARY KEY (uuid, genre),
+	FOREIGN KEY (uuid) REFERENCES series(uuid) ON DELETE CASCADE
+);
+
+-- Create series imprint table
+CREATE TABLE IF NOT EXISTS seriesImprint (
+    uuid    INT NOT NULL,
+	imprint TEXT NOT NULL,
+	PRIMARY KEY (uuid, imprint),
+	FOREIGN KEY (uuid) REFERENCES series(uuid) ON DELETE CASCADE
+);
+
+-- Create issue tag table
+CREATE TABLE IF NOT EXISTS issueTag (
+    uuid     INT NOT NULL,
+	category TEXT NOT NULL,
+	name     TEXT NOT NULL,
+	PRIMARY KEY (uuid, category, name),
+	FOREIGN KEY (uuid) REFERENCES issue(uuid) ON DELETE CASCADE
+);
+
+-- Create issue creator table
+CREATE TABLE IF NOT EXISTS issueCreator (
+	uuid        INT NOT NULL,
+	type        TEXT NOT NULL,
+	name        TEXT NOT NULL,
+	displayName TEXT NOT NULL,
+	PRIMARY KEY (uuid, type, name, displayName),
+	FOREIGN KEY (uuid) REFERENCES issue(uuid) ON DELETE CASCADE
+);`,
+	},
+	{
+		version:     2,
+		description: "add refresh checkpoints",
+		query: `CREATE TABLE refreshRun (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	incremental    INT NOT NULL,
+	status         TEXT NOT NULL DEFAULT 'running',
+	phase          TEXT NOT NULL DEFAULT 'series',
+	page           INT NOT NULL DEFAULT 0,
+	lastSeriesUUID TEXT NOT NULL DEFAULT '',
+	dateStarted    INT NOT NULL,
+	dateFinished   INT NOT NULL DEFAULT 0
+);`,
+	},
+	{
+		version:     3,
+		description: "index issue publication dates",
+		query:       `CREATE INDEX issuePublicationDate ON issue (publicationDate);`,
+	},
+	{
+		version:     4,
+		description: "add full-text search documents",
+		// searchIndex itself is an FTS5 table, created by setupSearch only if
+		// SQLite was built with FTS5. These tables exist either way so that a
+		// build without FTS5 can record that the index has fallen behind.
+		query: `CREATE TABLE searchDocument (
+	id   INTEGER PRIMARY KEY,
+	kind TEXT NOT NULL,
+	uuid TEXT NOT NULL,
+	UNIQUE (kind, uuid)
+);
+
+CREATE TABLE searchIndexState (
+	stale INT NOT NULL
+);
+
+INSERT INTO searchIndexState (stale) VALUES (1);`,
+	},
+	{
+		version:     5,
+		description: "index issues by series, creator and tag for filters",
+		query: `CREATE INDEX issueSeriesUUID ON issue (seriesUUID);
+
+CREATE INDEX issueCreatorName ON issueCreator (name COLLATE NOCASE);
+
+CREATE INDEX issueCreatorDisplayName ON issueCreator (displayName COLLATE NOCASE);
+
+CREATE INDEX issueTagName ON issueTag (name COLLATE NOCASE);`,
+	},
+	{
+		version:     6,
+		description: "record series changes made by each refresh",
+		query: `ALTER TABLE series ADD COLUMN lastSeenRun INT NOT NULL DEFAULT 0;
+
+CREATE TABLE seriesChange (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	runID       INT NOT NULL,
+	seriesUUID  TEXT NOT NULL,
+	kind        TEXT NOT NULL,
+	oldValue    TEXT NOT NULL,
+	newValue    TEXT NOT NULL,
+	dateChanged INT NOT NULL,
+	FOREIGN KEY (runID) REFERENCES refreshRun(id)
+);
+
+CREATE INDEX seriesChangeRun ON seriesChange (runID);
+
+CREATE INDEX seriesChangeSeries ON seriesChange (seriesUUID, kind);`,
+	},
+	{
+		version:     7,
+		description: "soft-delete series and issues removed from DCUI",
+		query: `ALTER TABLE series ADD COLUMN dateRemoved INT NOT NULL DEFAULT 0;
+
+ALTER TABLE issue ADD COLUMN lastSeenRun INT NOT NULL DEFAULT 0;
+
+ALTER TABLE issue ADD COLUMN dateRemoved INT NOT NULL DEFAULT 0;
+
+-- Series whose removal was recorded before they could be marked removed.
+UPDATE series
+SET dateRemoved = (
+	SELECT MAX(c.dateChanged)
+	FROM seriesChange c
+	WHERE c.seriesUUID = series.uuid
+		AND c.kind = 'removed'
+		AND c.runID > series.lastSeenRun
+)
+WHERE EXISTS (
+	SELECT 1
+	FROM seriesChange c
+	WHERE c.seriesUUID = series.uuid
+		AND c.kind = 'removed'
+		AND c.runID > series.lastSeenRun
+);
+
+UPDATE issue
+SET dateRemoved = (
+	SELECT dateRemoved
+	FROM series
+	WHERE uuid = issue.seriesUUID
+)
+WHERE seriesUUID IN (
+	SELECT uuid
+	FROM series
+	WHERE dateRemoved > 0
+);`,
+	},
+	{
+		version:     8,
+		description: "record the page size of each refresh",
+		// Refreshes before this one always asked for 100 series a page.
+		query: `ALTER TABLE refreshRun ADD COLUMN pageSize INT NOT NULL DEFAULT 100;`,
+	},
+	{
+		version:     9,
+		description: "add reading list tables",
+		query: `CREATE TABLE issueReading (
+	uuid     TEXT NOT NULL PRIMARY KEY,
+	read     INT NOT NULL DEFAULT 0,
+	dateRead INT NOT NULL DEFAULT 0,
+	rating   INT NOT NULL DEFAULT 0,
+	notes    TEXT NOT NULL DEFAULT '',
+	FOREIGN KEY (uuid) REFERENCES issue(uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE seriesFollow (
+	uuid         TEXT NOT NULL PRIMARY KEY,
+	dateFollowed INT NOT NULL,
+	FOREIGN KEY (uuid) REFERENCES series(uuid) ON DELETE CASCADE
+);`,
+	},
+	{
+		version:     10,
+		description: "add the watchlist and alerts",
+		// Issues stored before this have no first run and never alert.
+		query: `ALTER TABLE issue ADD COLUMN firstSeenRun INT NOT NULL DEFAULT 0;
+
+CREATE TABLE watch (
+	kind      TEXT NOT NULL,
+	value     TEXT NOT NULL COLLATE NOCASE,
+	dateAdded INT NOT NULL,
+	PRIMARY KEY (kind, value)
+);
+
+CREATE TABLE alert (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	issueUUID     TEXT NOT NULL UNIQUE,
+	runID         INT NOT NULL,
+	watchKind     TEXT NOT NULL,
+	watchValue    TEXT NOT NULL,
+	dateCreated   INT NOT NULL,
+	dateDismissed INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX alertDateDismissed ON alert (dateDismissed);`,
+	},
+	{
+		version:     11,
+		description: "index creators under canonical IDs",
+		// Each name DCUI uses for a creator is an alias of one creator.
+		// Names are grouped by display name, ignoring case, spaces, periods
+		// and hyphens, which refreshes continue to do for new names. A name
+		// whose display name is nothing but those stands in for it, so that
+		// such names are not all filed under one creator.
+		query: `CREATE TABLE creator (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	displayName TEXT NOT NULL
+);
+
+CREATE TABLE creatorAlias (
+	name      TEXT NOT NULL PRIMARY KEY,
+	nameKey   TEXT NOT NULL,
+	creatorID INT NOT NULL,
+	FOREIGN KEY (creatorID) REFERENCES creator(id)
+);
+
+CREATE INDEX creatorAliasNameKey ON creatorAlias (nameKey);
+
+CREATE INDEX creatorAliasCreatorID ON creatorAlias (creatorID);
+
+CREATE INDEX issueCreatorNameUUID ON issueCreator (name, uuid);
+
+CREATE TEMP VIEW creatorName AS
+SELECT
+	name,
+	CASE WHEN trim(displayName, ' .-') = '' THEN name ELSE displayName END AS displayName
+FROM issueCreator;
+
+INSERT INTO creator (displayName)
+SELECT MIN(displayName)
+FROM creatorName
+GROUP BY lower(replace(replace(replace(displayName, ' ', ''), '.', ''), '-', ''));
+
+INSERT INTO creatorAlias (name, nameKey, creatorID)
+SELECT
+	a.name,
+	a.nameKey,
+	cr.id
+FROM (
+	SELECT
+		name,
+		MIN(lower(replace(replace(replace(displayName, ' ', ''), '.', ''), '-', ''))) AS nameKey
+	FROM creatorName
+	GROUP BY name
+) a
+	JOIN creator cr ON lower(replace(replace(replace(cr.displayName, ' ', ''), '.', ''), '-', '')) = a.nameKey;
+
+-- Creators whose only display name belongs to a name with another.
+DELETE FROM creator
+WHERE id NOT IN (
+	SELECT creatorID
+	FROM creatorAlias
+);
+
+DROP VIEW creatorName;`,
+	},
+	{
+		version:     12,
+		description: "add reading orders",
+		// Print release dates are filled in as series are next updated.
+		query: `ALTER TABLE issue ADD COLUMN printRelease INT NOT NULL DEFAULT 0;
+
+CREATE TABLE readingOrder (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	name        TEXT NOT NULL,
+	tagCategory TEXT NOT NULL DEFAULT '',
+	tagName     TEXT NOT NULL DEFAULT '',
+	dateCreated INT NOT NULL,
+	dateUpdated INT NOT NULL
+);
+
+CREATE INDEX readingOrderTag ON readingOrder (tagCategory, tagName);
+
+CREATE TABLE readingOrderEntry (
+	orderID   INT NOT NULL,
+	issueUUID TEXT NOT NULL,
+	position  INT NOT NULL,
+	PRIMARY KEY (orderID, issueUUID),
+	FOREIGN KEY (orderID) REFERENCES readingOrder(id) ON DELETE CASCADE,
+	FOREIGN KEY (issueUUID) REFERENCES issue(uuid) ON DELETE CASCADE
+);
+
+CREATE INDEX readingOrderEntryPosition ON readingOrderEntry (orderID, position);`,
+	},
+	{
+		version:     13,
+		description: "track subscription plans and scheduled issues",
+		// Plans were never stored before this, so an issue's first plans are
+		// not recorded as a change.
+		query: `ALTER TABLE issue ADD COLUMN plansSeen INT NOT NULL DEFAULT 0;
+
+CREATE TABLE issuePlanChange (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	runID       INT NOT NULL,
+	issueUUID   TEXT NOT NULL,
+	oldPlans    TEXT NOT NULL,
+	newPlans    TEXT NOT NULL,
+	dateChanged INT NOT NULL,
+	FOREIGN KEY (runID) REFERENCES refreshRun(id)
+);
+
+CREATE INDEX issuePlanChangeIssue ON issuePlanChange (issueUUID);
+
+CREATE INDEX issuePlanChangeDate ON issuePlanChange (dateChanged);
+
+CREATE INDEX issueToAdd ON issue (toAdd);`,
+	},
+}
+
+// migrate brings the schema up to the latest version, applying each pending
+// migration in its own transaction.
+func (db Database) migrate() error {
+	db.log.Println("migrating database")
+
+	_, err := db.database.Exec(queries["createSchemaVersion"])
+	if err != nil {
+		err = fmt.Errorf("database.migrate: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	var current sql.NullInt64
+
+	err = db.database.QueryRow(queries["selectSchemaVersion"]).Scan(&current)
+	if err != nil {
+		err = fmt.Errorf("database.migrate: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	latest := migrations[len(migrations)-1].version
+	if int(current.Int64) > latest {
+		err = fmt.Errorf("database.migrate: schema version %v is newer than supported version %v",
+			current.Int64, latest)
+		db.log.Println(err)
+
+		return err
+	}
+
+	db.log.Printf("schema version %v, latest %v\n", current.Int64, latest)
+
+	for _, m := range migrations {
+		if m.version <= int(current.Int64) {
+			continue
+		}
+
+		err = db.applyMigration(m)
+		if err != nil {
+			err = fmt.Errorf("database.migrate: %w", err)
+			db.log.Println(err)
+
+			return err
+		}
+	}
+
+	db.log.Println("database migrated")
+
+	return nil
+}
+
+func (db Database) applyMigration(m migration) error {
+	db.log.Printf("applying migration %v: %v\n", m.version, m.description)
+
+	tx, err := db.database.Begin()
+	if err != nil {
+		err = fmt.Errorf("database.applyMigration: %w", err)
+
+		return err
+	}
+
+	_, err = tx.Exec(m.query)
+	if err != nil {
+		_ = tx.Rollback()
+
+		err = fmt.Errorf("database.applyMigration: version %v: %w", m.version, err)
+
+		return err
+	}
+
+	_, err = tx.Exec(queries["insertSchemaVersion"], m.version, m.description, time.Now().Unix())
+	if err != nil {
+		_ = tx.Rollback()
+
+		err = fmt.Errorf("database.applyMigration: version %v: %w", m.version, err)
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = fmt.Errorf("database.applyMigration: version %v: %w", m.version, err)
+
+		return err
+	}
+
+	return nil
+}
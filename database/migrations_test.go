@@ -0,0 +1,107 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateExistingDatabase(t *testing.T) {
+	home := newTestHome(t)
+
+	// A database created before schema versioning: the catalog tables exist
+	// with data in them, but there is no schemaVersion table.
+	legacy, err := sql.Open("sqlite3", filepath.Join(home, ".dcui", "dcui.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = legacy.Exec(migrations[0].query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = legacy.Exec(`INSERT INTO series (uuid, title, description, bookCount, issueCount, volumeCount,
+	omnibusCount, url)
+VALUES ('legacy', 'Legacy Series', 'Scraped long ago', 1, 1, 0, 0, 'https://example.com')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy.Close()
+
+	for range 2 {
+		db, err := New(Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertStrings(t, "series titles", queryStrings(t, db, "SELECT title FROM series"),
+			[]string{"Legacy Series"})
+
+		assertStrings(t, "schema version", queryStrings(t, db, "SELECT MAX(version) FROM schemaVersion"),
+			[]string{fmt.Sprint(migrations[len(migrations)-1].version)})
+
+		db.Close()
+	}
+}
+
+func TestMigrationsOrdered(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %v has version %v, want %v", i, m.version, i+1)
+		}
+	}
+}
+
+func TestMigrateCreatorIndex(t *testing.T) {
+	home := newTestHome(t)
+
+	// A database from before the creator index, with names whose display
+	// names have nothing to group them by.
+	legacy, err := sql.Open("sqlite3", filepath.Join(home, ".dcui", "dcui.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = legacy.Exec(queries["createSchemaVersion"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range migrations[:10] {
+		_, err = legacy.Exec(m.query)
+		if err == nil {
+			_, err = legacy.Exec(queries["insertSchemaVersion"], m.version, m.description, 0)
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = legacy.Exec(`INSERT INTO issueCreator (uuid, type, name, displayName)
+VALUES
+	('i1', 'author', 'tom-king', 'Tom King'),
+	('i2', 'author', 'tom-king-1', 'tom king'),
+	('i1', 'inker', 'anon-1', ''),
+	('i2', 'inker', 'anon-2', ' . ')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy.Close()
+
+	db, err := New(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	assertStrings(t, "creators", queryStrings(t, db, `SELECT a.name || ' ' || a.nameKey || ' ' || c.displayName
+FROM creatorAlias a
+	JOIN creator c ON c.id = a.creatorID
+ORDER BY a.name`), []string{"anon-1 anon1 anon-1", "anon-2 anon2 anon-2", "tom-king tomking Tom King",
+		"tom-king-1 tomking Tom King"})
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PlanChange is a change to the subscription plans an issue is exclusive to,
+// found by a refresh.
+type PlanChange struct {
+	RunID   int64
+	Changed time.Time
+	// OldPlans and NewPlans are the plans before and after the change, and
+	// are empty for the base tier.
+	OldPlans []string
+	NewPlans []string
+	Issue    Issue
+}
+
+// MovedToBaseTier reports whether the change made the issue available in
+// every plan.
+func (c PlanChange) MovedToBaseTier() bool {
+	return len(c.OldPlans) > 0 && len(c.NewPlans) == 0
+}
+
+// joinPlans stores the plans of an issue, as given by the DCUI API, in the
+// form of issue.subscription: sorted and comma-separated.
+func joinPlans(plans []string) string {
+	var tidy []string
+
+	for _, p := range plans {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			tidy = append(tidy, p)
+		}
+	}
+
+	slices.Sort(tidy)
+
+	return strings.Join(slices.Compact(tidy), ",")
+}
+
+// splitPlans reads plans stored by joinPlans.
+func splitPlans(plans string) []string {
+	if plans == "" {
+		return nil
+	}
+
+	return strings.Split(plans, ",")
+}
+
+// PlanChanges returns the changes to issues' plans found at or after from and
+// before to, newest first. A zero from or to leaves that end of the range
+// open. Changes are only found for issues whose plans were stored by an
+// earlier refresh.
+func (db Database) PlanChanges(ctx context.Context, from, to time.Time) ([]PlanChange, error) {
+	fromUnix, toUnix := unixRange(from, to)
+
+	rows, err := db.database.QueryContext(ctx, queries["selectPlanChanges"], fromUnix, toUnix)
+	if err != nil {
+		err = fmt.Errorf("database.PlanChanges: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []PlanChange
+
+	for rows.Next() {
+		var (
+			c                  PlanChange
+			oldPlans, newPlans string
+			changed            int64
+		)
+
+		c.Issue, err = scanIssue(rows, &c.RunID, &oldPlans, &newPlans, &changed)
+		if err != nil {
+			err = fmt.Errorf("database.PlanChanges: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+
+		c.OldPlans = splitPlans(oldPlans)
+		c.NewPlans = splitPlans(newPlans)
+		c.Changed = unixTime(changed)
+		changes = append(changes, c)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("database.PlanChanges: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPlanChanges(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+	ctx := context.Background()
+
+	err := db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The plans stored by the first refresh are not changes.
+	changes, err := db.PlanChanges(ctx, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 0 {
+		t.Errorf("first refresh found plan changes %+v, want none", changes)
+	}
+
+	issues, _, err := db.QueryIssues(ctx, ExclusiveTo("Premium"), QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "premium issues", issueTitles(issues), []string{"Batman (2016-) #2"})
+
+	if fmt.Sprint(issues[0].Plans) != "[premium]" {
+		t.Errorf("Plans = %v, want [premium]", issues[0].Plans)
+	}
+
+	// Batman #2 moves to the base tier while #1 becomes Ultra-only and is
+	// scheduled to be added in a year.
+	scheduled := time.Now().AddDate(1, 0, 0).UTC().Format(time.DateOnly)
+
+	fs.editFixture(t, "books", batmanUUID, func(fixture map[string]any) {
+		values := fixture["values"].([]any)
+		values[0].(map[string]any)["exclusive_to_plans"] = []any{" ultra", "ultra"}
+		values[0].(map[string]any)["publish_date"] = scheduled
+		values[1].(map[string]any)["exclusive_to_plans"] = []any{}
+	})
+
+	start := time.Now().Add(-time.Second)
+
+	err = db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err = db.PlanChanges(ctx, start, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range changes {
+		got = append(got, fmt.Sprintf("%v %v->%v %v", c.Issue.UUID, c.OldPlans, c.NewPlans, c.MovedToBaseTier()))
+	}
+
+	assertStrings(t, "changes", got, []string{
+		batman10UUID + " [premium]->[] true",
+		batman9UUID + " []->[ultra] false",
+	})
+
+	for _, test := range []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"ultra", ExclusiveTo("ultra"), []string{"Batman (2016-) #1"}},
+		{"moved", MovedToBaseTier(start, time.Time{}), []string{"Batman (2016-) #2"}},
+		{"moved before", MovedToBaseTier(time.Time{}, start), nil},
+		{"scheduled", ScheduledToBeAdded(time.Now()), []string{"Batman (2016-) #1"}},
+	} {
+		issues, _, err := db.QueryIssues(ctx, test.filter, QueryOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertStrings(t, test.name, issueTitles(issues), test.want)
+	}
+
+	series, _, err := db.QuerySeries(ctx, MovedToBaseTier(start, time.Time{}), QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(series) != 1 || series[0].UUID != batmanUUID {
+		t.Errorf("series moved to the base tier = %+v, want Batman", series)
+	}
+
+	issues, _, err = db.QueryIssues(ctx, ExclusiveTo("ultra"), QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if issues[0].ToAdd.Format(time.DateOnly) != scheduled {
+		t.Errorf("ToAdd = %v, want %v", issues[0].ToAdd, scheduled)
+	}
+}
+
+func TestJoinPlans(t *testing.T) {
+	for _, test := range []struct {
+		plans []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{" "}, ""},
+		{[]string{"ultra", "premium", "ultra "}, "premium,ultra"},
+	} {
+		got := joinPlans(test.plans)
+		if got != test.want {
+			t.Errorf("joinPlans(%q) = %q, want %q", test.plans, got, test.want)
+		}
+	}
+}
@@ -2,90 +2,955 @@ package database
 
 //nolint:gochecknoglobals
 var queries = map[string]string{
-	// Create entire database:
-	"createDatabase": `-- Turn on foreign key support
-PRAGMA foreign_keys = ON;
-
--- Drop any existing tables
-DROP TABLE IF EXISTS issueCreator;
-DROP TABLE IF EXISTS issueTag;
-DROP TABLE IF EXISTS seriesImprint;
-DROP TABLE IF EXISTS seriesGenre;
-DROP TABLE IF EXISTS issue;
-DROP TABLE IF EXISTS series;
-
--- Create series table
-CREATE TABLE series (
-	uuid         TEXT NOT NULL PRIMARY KEY,
-	title        TEXT NOT NULL,
-	description  TEXT NOT NULL,
-	bookCount    INT NOT NULL,
-	issueCount   INT NOT NULL,
-	volumeCount  INT NOT NULL,
-	omnibusCount INT NOT NULL,
-	url          TEXT NOT NULL,
-	dateUpdated  INT NOT NULL DEFAULT 0,
-	needUpdate   INT NOT NULL DEFAULT 1
-);
+	// create the schema version table
+	"createSchemaVersion": `CREATE TABLE IF NOT EXISTS schemaVersion (
+	version     INT NOT NULL PRIMARY KEY,
+	description TEXT NOT NULL,
+	dateApplied INT NOT NULL
+);`,
+	// current schema version, NULL if no migrations have been applied
+	"selectSchemaVersion": `SELECT MAX(version)
+FROM schemaVersion;`,
+	// record an applied migration
+	"insertSchemaVersion": `INSERT INTO schemaVersion (
+	version,
+	description,
+	dateApplied
+)
+VALUES
+	(?, ?, ?);`,
+	// series whose descriptions and issues need to be downloaded
+	"selectSeriesNeedingUpdate": `SELECT
+	uuid,
+	title
+FROM series
+WHERE needUpdate = 1
+	AND dateRemoved = 0
+	AND uuid > ?
+ORDER BY uuid;`,
+	// most recent refresh that was interrupted before finishing
+	"selectInterruptedRun": `SELECT
+	id,
+	incremental,
+	phase,
+	page,
+	pageSize,
+	lastSeriesUUID
+FROM refreshRun
+WHERE status = 'running'
+ORDER BY id DESC
+LIMIT 1;`,
+	// start a new refresh
+	"insertRun": `INSERT INTO refreshRun (
+	incremental,
+	pageSize,
+	dateStarted
+)
+VALUES
+	(?, ?, ?);`,
+	// give up on interrupted refreshes
+	"abandonRuns": `UPDATE refreshRun
+SET
+	status = 'abandoned',
+	dateFinished = ?
+WHERE status = 'running';`,
+	// summary counts for Stats
+	"selectStats": `SELECT
+	(SELECT COUNT(*) FROM series WHERE dateRemoved = 0),
+	(SELECT COUNT(*) FROM issue WHERE dateRemoved = 0),
+	(SELECT COUNT(*) FROM creator),
+	(SELECT COUNT(DISTINCT genre) FROM seriesGenre),
+	(SELECT COUNT(DISTINCT imprint) FROM seriesImprint),
+	(SELECT COUNT(*) FROM series WHERE needUpdate = 1 AND dateRemoved = 0),
+	(SELECT COUNT(*) FROM series WHERE dateRemoved > 0),
+	(SELECT COALESCE(MAX(dateFinished), 0) FROM refreshRun WHERE status = 'complete'),
+	EXISTS (SELECT 1 FROM refreshRun WHERE status = 'running');`,
+	// series with a title containing the second pattern, those matching the
+	// third (prefix) pattern first; removed series are only included if the
+	// first parameter is true
+	"searchSeriesTitle": `SELECT
+	uuid,
+	title,
+	description,
+	bookCount,
+	issueCount,
+	volumeCount,
+	omnibusCount,
+	url,
+	dateUpdated,
+	dateRemoved
+FROM series
+WHERE (? OR dateRemoved = 0)
+	AND title LIKE ? ESCAPE '\'
+ORDER BY
+	title LIKE ? ESCAPE '\' DESC,
+	title COLLATE NOCASE,
+	uuid;`,
+	// series matching a Filter, %s being its condition, paged by the limit
+	// and offset
+	"selectSeriesWhere": `SELECT
+	s.uuid,
+	s.title,
+	s.description,
+	s.bookCount,
+	s.issueCount,
+	s.volumeCount,
+	s.omnibusCount,
+	s.url,
+	s.dateUpdated,
+	s.dateRemoved
+FROM series s
+WHERE %s
+ORDER BY
+	s.title COLLATE NOCASE,
+	s.uuid
+LIMIT ? OFFSET ?;`,
+	// number of series matching a Filter, %s being its condition
+	"countSeriesWhere": `SELECT COUNT(*)
+FROM series s
+WHERE %s;`,
+	// issues and their series matching a Filter, %s being its condition,
+	// paged by the limit and offset
+	"selectIssuesWhere": `SELECT
+	i.uuid,
+	i.title,
+	i.description,
+	i.publisher,
+	i.imprint,
+	i.issueNumber,
+	i.pages,
+	i.publicationDate,
+	i.url,
+	i.dateRemoved,
+	i.subscription,
+	COALESCE(i.toAdd, ''),
+	s.uuid,
+	s.title,
+	s.description,
+	s.bookCount,
+	s.issueCount,
+	s.volumeCount,
+	s.omnibusCount,
+	s.url,
+	s.dateUpdated,
+	s.dateRemoved
+FROM issue i
+	JOIN series s ON s.uuid = i.seriesUUID
+WHERE %s
+ORDER BY
+	i.publicationDate DESC,
+	s.title COLLATE NOCASE,
+	i.issueNumber,
+	i.uuid
+LIMIT ? OFFSET ?;`,
+	// number of issues matching a Filter, %s being its condition
+	"countIssuesWhere": `SELECT COUNT(*)
+FROM issue i
+	JOIN series s ON s.uuid = i.seriesUUID
+WHERE %s;`,
+	// series matching a Filter, %s being its condition, with their genres and
+	// imprints as JSON arrays
+	"exportSeriesWhere": `SELECT
+	s.uuid,
+	s.title,
+	s.description,
+	s.bookCount,
+	s.issueCount,
+	s.volumeCount,
+	s.omnibusCount,
+	s.url,
+	s.dateUpdated,
+	s.dateRemoved,
+	(
+		SELECT json_group_array(genre)
+		FROM (
+			SELECT genre
+			FROM seriesGenre
+			WHERE uuid = s.uuid
+			ORDER BY genre
+		)
+	),
+	(
+		SELECT json_group_array(imprint)
+		FROM (
+			SELECT imprint
+			FROM seriesImprint
+			WHERE uuid = s.uuid
+			ORDER BY imprint
+		)
+	)
+FROM series s
+WHERE %s
+ORDER BY
+	s.title COLLATE NOCASE,
+	s.uuid
+LIMIT ? OFFSET ?;`,
+	// issues matching a Filter, %s being its condition, with their creators
+	// and tags as JSON arrays of objects
+	"exportIssuesWhere": `SELECT
+	i.uuid,
+	s.uuid,
+	s.title,
+	i.title,
+	i.description,
+	i.publisher,
+	i.imprint,
+	i.issueNumber,
+	i.pages,
+	i.publicationDate,
+	i.url,
+	i.dateRemoved,
+	(
+		SELECT json_group_array(json_object('role', type, 'name', name, 'displayName', displayName))
+		FROM (
+			SELECT
+				type,
+				name,
+				displayName
+			FROM issueCreator
+			WHERE uuid = i.uuid
+			ORDER BY
+				type,
+				displayName
+		)
+	),
+	(
+		SELECT json_group_array(json_object('category', category, 'name', name))
+		FROM (
+			SELECT
+				category,
+				name
+			FROM issueTag
+			WHERE uuid = i.uuid
+			ORDER BY
+				category,
+				name
+		)
+	)
+FROM issue i
+	JOIN series s ON s.uuid = i.seriesUUID
+WHERE %s
+ORDER BY
+	s.title COLLATE NOCASE,
+	i.issueNumber,
+	i.uuid
+LIMIT ? OFFSET ?;`,
+	// every genre
+	"selectGenres": `SELECT DISTINCT genre
+FROM seriesGenre
+ORDER BY genre COLLATE NOCASE;`,
+	// every imprint
+	"selectImprints": `SELECT DISTINCT imprint
+FROM seriesImprint
+ORDER BY imprint COLLATE NOCASE;`,
+	// refreshes, newest first, with how many changes each recorded
+	"selectRefreshRuns": `SELECT
+	r.id,
+	r.incremental,
+	r.status,
+	r.dateStarted,
+	r.dateFinished,
+	(SELECT COUNT(*) FROM seriesChange c WHERE c.runID = r.id)
+FROM refreshRun r
+ORDER BY r.id DESC
+LIMIT ?;`,
+	// the last refresh to finish, 0 if none has
+	"selectLastCompleteRun": `SELECT COALESCE(MAX(id), 0)
+FROM refreshRun
+WHERE status = 'complete';`,
+	// series changes, %s being the condition selecting them, grouped by kind
+	"selectChangesWhere": `SELECT
+	c.runID,
+	c.dateChanged,
+	c.kind,
+	c.seriesUUID,
+	COALESCE(s.title, ''),
+	c.oldValue,
+	c.newValue
+FROM seriesChange c
+	LEFT JOIN series s ON s.uuid = c.seriesUUID
+WHERE %s
+ORDER BY
+	CASE c.kind
+		WHEN 'new' THEN 0
+		WHEN 'removed' THEN 1
+		WHEN 'restored' THEN 2
+		WHEN 'bookCountIncreased' THEN 3
+		ELSE 4
+	END,
+	s.title COLLATE NOCASE,
+	c.id;`,
+	// whether SQLite was built with FTS5
+	"selectFTS5Enabled": `SELECT sqlite_compileoption_used('ENABLE_FTS5');`,
+	// create the full-text index; its rowids are searchDocument ids
+	"createSearchIndex": `CREATE VIRTUAL TABLE IF NOT EXISTS searchIndex USING fts5 (
+	title,
+	description,
+	tokenize = 'unicode61 remove_diacritics 2'
+);`,
+	// whether the full-text index must be rebuilt
+	"selectSearchIndexStale": `SELECT stale
+FROM searchIndexState;`,
+	// record that the full-text index has fallen behind the catalog
+	"markSearchIndexStale": `UPDATE searchIndexState
+SET stale = 1;`,
+	// rebuild the full-text index from the catalog
+	"rebuildSearchIndex": `DELETE FROM searchIndex;
 
--- Create issue table
-CREATE TABLE issue (
-	uuid            TEXT NOT NULL PRIMARY KEY,
-	seriesUUID      INT NOT NULL,
-	title           TEXT NOT NULL,
-	description     TEXT NOT NULL,
-	publisher       TEXT NOT NULL,
-	imprint         TEXT NOT NULL,
-	issueNumber     TEXT NOT NULL,
-	pages           INT NOT NULL,
-	publicationDate INT NOT NULL,
-	url             TEXT NOT NULL,
-	subscription    TEXT NOT NULL,
-	toAdd           TEXT,
-	FOREIGN KEY (seriesUUID) REFERENCES series(uuid) ON DELETE CASCADE
-);
+INSERT INTO searchDocument (kind, uuid)
+SELECT 'series', uuid
+FROM series
+WHERE true
+ON CONFLICT DO NOTHING;
 
--- Create series genre table
-CREATE TABLE seriesGenre (
-	uuid  INT NOT NULL,
-	genre TEXT NOT NULL,
-	PRIMARY KEY (uuid, genre),
-	FOREIGN KEY (uuid) REFERENCES series(uuid) ON DELETE CASCADE
-);
+INSERT INTO searchDocument (kind, uuid)
+SELECT 'issue', uuid
+FROM issue
+WHERE true
+ON CONFLICT DO NOTHING;
 
--- Create series imprint table
-CREATE TABLE seriesImprint (
-    uuid    INT NOT NULL,
-	imprint TEXT NOT NULL,
-	PRIMARY KEY (uuid, imprint),
-	FOREIGN KEY (uuid) REFERENCES series(uuid) ON DELETE CASCADE
-);
+INSERT INTO searchIndex (rowid, title, description)
+SELECT
+	d.id,
+	s.title,
+	s.description
+FROM searchDocument d
+	JOIN series s ON s.uuid = d.uuid
+WHERE d.kind = 'series';
 
--- Create issue tag table
-CREATE TABLE issueTag (
-    uuid     INT NOT NULL,
-	category TEXT NOT NULL,
-	name     TEXT NOT NULL,
-	PRIMARY KEY (uuid, category, name),
-	FOREIGN KEY (uuid) REFERENCES issue(uuid) ON DELETE CASCADE
-);
+INSERT INTO searchIndex (rowid, title, description)
+SELECT
+	d.id,
+	i.title,
+	i.description
+FROM searchDocument d
+	JOIN issue i ON i.uuid = d.uuid
+WHERE d.kind = 'issue';
 
--- Create issue creator table
-CREATE TABLE issueCreator (
-	uuid        INT NOT NULL,
-	type        TEXT NOT NULL,
-	name        TEXT NOT NULL,
-	displayName TEXT NOT NULL,
-	PRIMARY KEY (uuid, type, name, displayName),
-	FOREIGN KEY (uuid) REFERENCES issue(uuid) ON DELETE CASCADE
-);`,
-	// query to verify if database tables exist
-	"pingDatabase": `SELECT *
+UPDATE searchIndexState
+SET stale = 0;`,
+	// full-text search of series and issues, best match first, including
+	// those removed from DCUI only if the second parameter is true; the
+	// snippet marks matches with char(2) and char(3)
+	"searchFullText": `SELECT
+	d.kind,
+	d.uuid,
+	COALESCE(s.title, i.title),
+	COALESCE(s.url, i.url),
+	COALESCE(i.seriesUUID, ''),
+	COALESCE(si.title, ''),
+	snippet(searchIndex, -1, char(2), char(3), '…', 16),
+	COALESCE(s.dateRemoved, i.dateRemoved) <> 0,
+	bm25(searchIndex, 10.0, 1.0) AS rank
+FROM searchIndex
+	JOIN searchDocument d ON d.id = searchIndex.rowid
+	LEFT JOIN series s ON d.kind = 'series' AND s.uuid = d.uuid
+	LEFT JOIN issue i ON d.kind = 'issue' AND i.uuid = d.uuid
+	LEFT JOIN series si ON si.uuid = i.seriesUUID
+WHERE searchIndex MATCH ?
+	AND (? OR COALESCE(s.dateRemoved, i.dateRemoved) = 0)
+ORDER BY rank
+LIMIT ?;`,
+	// what has been recorded about reading an issue
+	"selectReading": `SELECT
+	read,
+	dateRead,
+	rating,
+	notes
+FROM issueReading
+WHERE uuid = ?;`,
+	// record whether an issue has been read and when; parameters are the
+	// read flag, date and issue, which must exist
+	"upsertReadStatus": `INSERT INTO issueReading (uuid, read, dateRead)
+SELECT uuid, ?1, ?2
+FROM issue
+WHERE uuid = ?3
+ON CONFLICT DO UPDATE SET
+	read = excluded.read,
+	dateRead = excluded.dateRead;`,
+	// rate an issue; parameters are the rating and issue, which must exist
+	"upsertRating": `INSERT INTO issueReading (uuid, rating)
+SELECT uuid, ?1
+FROM issue
+WHERE uuid = ?2
+ON CONFLICT DO UPDATE SET
+	rating = excluded.rating;`,
+	// note an issue; parameters are the notes and issue, which must exist
+	"upsertNotes": `INSERT INTO issueReading (uuid, notes)
+SELECT uuid, ?1
+FROM issue
+WHERE uuid = ?2
+ON CONFLICT DO UPDATE SET
+	notes = excluded.notes;`,
+	// follow a series, which must exist; parameters are the date and series
+	"insertFollow": `INSERT INTO seriesFollow (uuid, dateFollowed)
+SELECT uuid, ?
 FROM series
+WHERE uuid = ?
+ON CONFLICT DO NOTHING;`,
+	// stop following a series
+	"deleteFollow": `DELETE FROM seriesFollow
+WHERE uuid = ?;`,
+	// whether a series is followed
+	"selectFollowing": `SELECT EXISTS (
+	SELECT 1
+	FROM seriesFollow
+	WHERE uuid = ?
+);`,
+	// followed series, in title order
+	"selectFollowedSeries": `SELECT
+	s.uuid,
+	s.title,
+	s.description,
+	s.bookCount,
+	s.issueCount,
+	s.volumeCount,
+	s.omnibusCount,
+	s.url,
+	s.dateUpdated,
+	s.dateRemoved
+FROM seriesFollow f
+	JOIN series s ON s.uuid = f.uuid
+ORDER BY
+	s.title COLLATE NOCASE,
+	s.uuid;`,
+	// the first unread issue, by issue number, of each followed series still
+	// on DCUI, in series title order
+	"selectContinueReading": `SELECT
+	i.uuid,
+	i.title,
+	i.description,
+	i.publisher,
+	i.imprint,
+	i.issueNumber,
+	i.pages,
+	i.publicationDate,
+	i.url,
+	i.dateRemoved,
+	i.subscription,
+	COALESCE(i.toAdd, ''),
+	s.uuid,
+	s.title,
+	s.description,
+	s.bookCount,
+	s.issueCount,
+	s.volumeCount,
+	s.omnibusCount,
+	s.url,
+	s.dateUpdated,
+	s.dateRemoved
+FROM seriesFollow f
+	JOIN series s ON s.uuid = f.uuid
+	JOIN issue i ON i.uuid = (
+		SELECT n.uuid
+		FROM issue n
+			LEFT JOIN issueReading r ON r.uuid = n.uuid
+		WHERE n.seriesUUID = s.uuid
+			AND n.dateRemoved = 0
+			AND COALESCE(r.read, 0) = 0
+		ORDER BY
+			CAST(n.issueNumber AS REAL),
+			n.issueNumber,
+			n.uuid
+		LIMIT 1
+	)
+WHERE s.dateRemoved = 0
+ORDER BY
+	s.title COLLATE NOCASE,
+	s.uuid;`,
+	// every unread issue of the followed series still on DCUI, by series
+	// title then issue number
+	"selectUnreadFollowed": `SELECT
+	i.uuid,
+	i.title,
+	i.description,
+	i.publisher,
+	i.imprint,
+	i.issueNumber,
+	i.pages,
+	i.publicationDate,
+	i.url,
+	i.dateRemoved,
+	i.subscription,
+	COALESCE(i.toAdd, ''),
+	s.uuid,
+	s.title,
+	s.description,
+	s.bookCount,
+	s.issueCount,
+	s.volumeCount,
+	s.omnibusCount,
+	s.url,
+	s.dateUpdated,
+	s.dateRemoved
+FROM seriesFollow f
+	JOIN series s ON s.uuid = f.uuid
+	JOIN issue i ON i.seriesUUID = s.uuid
+	LEFT JOIN issueReading r ON r.uuid = i.uuid
+WHERE s.dateRemoved = 0
+	AND i.dateRemoved = 0
+	AND COALESCE(r.read, 0) = 0
+ORDER BY
+	s.title COLLATE NOCASE,
+	s.uuid,
+	CAST(i.issueNumber AS REAL),
+	i.issueNumber,
+	i.uuid;`,
+	// start watching for new issues; parameters are the kind, value and date
+	"insertWatch": `INSERT INTO watch (kind, value, dateAdded)
+VALUES
+	(?, ?, ?)
+ON CONFLICT DO NOTHING;`,
+	// stop watching for new issues; parameters are the kind and value
+	"deleteWatch": `DELETE FROM watch
+WHERE kind = ?
+	AND value = ?;`,
+	// whether something is watched; parameters are the kind and value
+	"selectWatching": `SELECT EXISTS (
+	SELECT 1
+	FROM watch
+	WHERE kind = ?
+		AND value = ?
+);`,
+	// whether a series is in the database, removed from DCUI or not
+	"selectSeriesExists": `SELECT EXISTS (
+	SELECT 1
+	FROM series
+	WHERE uuid = ?
+);`,
+	// the watchlist, with the titles of watched series
+	"selectWatches": `SELECT
+	w.kind,
+	w.value,
+	COALESCE(s.title, ''),
+	w.dateAdded
+FROM watch w
+	LEFT JOIN series s ON w.kind = 'series' AND s.uuid = w.value
+ORDER BY
+	w.kind,
+	COALESCE(s.title, w.value) COLLATE NOCASE;`,
+	// most recent alert, 0 if there are none
+	"selectLastAlert": `SELECT COALESCE(MAX(id), 0)
+FROM alert;`,
+	// alerts not yet dismissed with a higher id than given, with their
+	// issues, newest first
+	"selectPendingAlerts": `SELECT
+	i.uuid,
+	i.title,
+	i.description,
+	i.publisher,
+	i.imprint,
+	i.issueNumber,
+	i.pages,
+	i.publicationDate,
+	i.url,
+	i.dateRemoved,
+	i.subscription,
+	COALESCE(i.toAdd, ''),
+	s.uuid,
+	s.title,
+	s.description,
+	s.bookCount,
+	s.issueCount,
+	s.volumeCount,
+	s.omnibusCount,
+	s.url,
+	s.dateUpdated,
+	s.dateRemoved,
+	a.id,
+	a.runID,
+	a.watchKind,
+	a.watchValue,
+	COALESCE(ws.title, ''),
+	a.dateCreated
+FROM alert a
+	JOIN issue i ON i.uuid = a.issueUUID
+	JOIN series s ON s.uuid = i.seriesUUID
+	LEFT JOIN series ws ON a.watchKind = 'series' AND ws.uuid = a.watchValue
+WHERE a.dateDismissed = 0
+	AND a.id > ?
+ORDER BY
+	a.id DESC;`,
+	// dismiss an alert; parameters are the date and alert
+	"dismissAlert": `UPDATE alert
+SET dateDismissed = ?
+WHERE id = ?
+	AND dateDismissed = 0;`,
+	// dismiss every alert up to an ID; parameters are the date and ID
+	"dismissAllAlerts": `UPDATE alert
+SET dateDismissed = ?
+WHERE dateDismissed = 0
+	AND id <= ?;`,
+	// whether a refresh has ever finished, so that issues can be new
+	"selectAnyCompleteRun": `SELECT EXISTS (
+	SELECT 1
+	FROM refreshRun
+	WHERE status = 'complete'
+);`,
+	// creators with a display name or alias containing the pattern, with the
+	// number of issues they have in each role and in all
+	"selectCreators": `SELECT
+	cr.id,
+	cr.displayName,
+	(
+		SELECT COUNT(DISTINCT ac.uuid)
+		FROM creatorAlias aa
+			JOIN issueCreator ac ON ac.name = aa.name
+		WHERE aa.creatorID = cr.id
+	),
+	c.type,
+	COUNT(DISTINCT c.uuid)
+FROM creator cr
+	JOIN creatorAlias a ON a.creatorID = cr.id
+	JOIN issueCreator c ON c.name = a.name
+WHERE cr.displayName LIKE ? ESCAPE '\'
+	OR EXISTS (
+		SELECT 1
+		FROM creatorAlias la
+		WHERE la.creatorID = cr.id
+			AND la.name LIKE ? ESCAPE '\'
+	)
+GROUP BY
+	cr.id,
+	c.type
+ORDER BY
+	cr.displayName COLLATE NOCASE,
+	cr.id,
+	c.type;`,
+	// a creator's display name and the number of issues they are credited on
+	"selectCreator": `SELECT
+	cr.displayName,
+	(
+		SELECT COUNT(DISTINCT c.uuid)
+		FROM creatorAlias a
+			JOIN issueCreator c ON c.name = a.name
+		WHERE a.creatorID = cr.id
+	)
+FROM creator cr
+WHERE cr.id = ?;`,
+	// the number of issues a creator is credited on in each role
+	"selectCreatorRoles": `SELECT
+	c.type,
+	COUNT(DISTINCT c.uuid)
+FROM creatorAlias a
+	JOIN issueCreator c ON c.name = a.name
+WHERE a.creatorID = ?
+GROUP BY c.type
+ORDER BY c.type;`,
+	// the names a creator is credited under
+	"selectCreatorAliases": `SELECT name
+FROM creatorAlias
+WHERE creatorID = ?
+ORDER BY name;`,
+	// the issues a creator is credited on, with the role, by role, series
+	// and issue number
+	"selectCreatorCredits": `SELECT
+	i.uuid,
+	i.title,
+	i.description,
+	i.publisher,
+	i.imprint,
+	i.issueNumber,
+	i.pages,
+	i.publicationDate,
+	i.url,
+	i.dateRemoved,
+	i.subscription,
+	COALESCE(i.toAdd, ''),
+	s.uuid,
+	s.title,
+	s.description,
+	s.bookCount,
+	s.issueCount,
+	s.volumeCount,
+	s.omnibusCount,
+	s.url,
+	s.dateUpdated,
+	s.dateRemoved,
+	c.type
+FROM creatorAlias a
+	JOIN issueCreator c ON c.name = a.name
+	JOIN issue i ON i.uuid = c.uuid
+	JOIN series s ON s.uuid = i.seriesUUID
+WHERE a.creatorID = ?
+GROUP BY
+	c.type,
+	i.uuid
+ORDER BY
+	c.type,
+	s.title COLLATE NOCASE,
+	s.uuid,
+	CAST(i.issueNumber AS REAL),
+	i.issueNumber,
+	i.uuid;`,
+	// the creators sharing the most issues with a creator, limited by the
+	// second parameter
+	"selectTopCollaborators": `SELECT
+	ca.creatorID,
+	cr.displayName,
+	COUNT(DISTINCT c.uuid) AS shared
+FROM creatorAlias a
+	JOIN issueCreator mine ON mine.name = a.name
+	JOIN issueCreator c ON c.uuid = mine.uuid
+	JOIN creatorAlias ca ON ca.name = c.name
+	JOIN creator cr ON cr.id = ca.creatorID
+WHERE a.creatorID = ?
+	AND ca.creatorID <> a.creatorID
+GROUP BY ca.creatorID
+ORDER BY
+	shared DESC,
+	cr.displayName COLLATE NOCASE,
+	ca.creatorID
+LIMIT ?;`,
+	// move every alias of the second creator to the first, if it exists
+	"mergeCreatorAliases": `UPDATE creatorAlias
+SET creatorID = ?1
+WHERE creatorID = ?2
+	AND EXISTS (
+		SELECT 1
+		FROM creator
+		WHERE id = ?1
+	);`,
+	// delete a creator left without aliases by a merge
+	"deleteCreator": `DELETE FROM creator
+WHERE id = ?
+	AND NOT EXISTS (
+		SELECT 1
+		FROM creatorAlias
+		WHERE creatorID = creator.id
+	);`,
+	// tags with a category, such as events and storylines, with the number
+	// of issues still on DCUI that have them
+	"selectTagGroups": `SELECT
+	t.category,
+	t.name,
+	COUNT(DISTINCT t.uuid)
+FROM issueTag t
+	JOIN issue i ON i.uuid = t.uuid
+WHERE t.category <> ''
+	AND i.dateRemoved = 0
+GROUP BY
+	t.category,
+	t.name
+ORDER BY
+	t.name COLLATE NOCASE,
+	t.category;`,
+	// the issues still on DCUI with a tag, in the order they were released
+	// in print, or published on DCUI if their print release is unknown
+	"selectTaggedIssueUUIDs": `SELECT i.uuid
+FROM issueTag t
+	JOIN issue i ON i.uuid = t.uuid
+	JOIN series s ON s.uuid = i.seriesUUID
+WHERE t.category = ?
+	AND t.name = ?
+	AND i.dateRemoved = 0
+ORDER BY
+	CASE WHEN i.printRelease > 0 THEN i.printRelease ELSE i.publicationDate END,
+	i.publicationDate,
+	s.title COLLATE NOCASE,
+	CAST(i.issueNumber AS REAL),
+	i.issueNumber,
+	i.uuid;`,
+	// reading orders with their number of issues, by name
+	"selectReadingOrders": `SELECT
+	o.id,
+	o.name,
+	o.tagCategory,
+	o.tagName,
+	o.dateCreated,
+	o.dateUpdated,
+	(
+		SELECT COUNT(*)
+		FROM readingOrderEntry
+		WHERE orderID = o.id
+	)
+FROM readingOrder o
+ORDER BY
+	o.name COLLATE NOCASE,
+	o.id;`,
+	// the reading order built from a tag
+	"selectTagReadingOrder": `SELECT id
+FROM readingOrder
+WHERE tagCategory = ?
+	AND tagName = ?
+ORDER BY id
 LIMIT 1;`,
+	// create a reading order
+	"insertReadingOrder": `INSERT INTO readingOrder (name, tagCategory, tagName, dateCreated, dateUpdated)
+VALUES (?, ?, ?, ?, ?);`,
+	// rename a reading order; the parameters are the name, date and ID
+	"renameReadingOrder": `UPDATE readingOrder
+SET name = ?,
+	dateUpdated = ?
+WHERE id = ?;`,
+	// record that a reading order's issues are being replaced
+	"touchReadingOrder": `UPDATE readingOrder
+SET dateUpdated = ?
+WHERE id = ?;`,
+	// delete a reading order
+	"deleteReadingOrder": `DELETE FROM readingOrder
+WHERE id = ?;`,
+	// delete the issues of a reading order
+	"deleteReadingOrderEntries": `DELETE FROM readingOrderEntry
+WHERE orderID = ?;`,
+	// add a known issue to a reading order at a position
+	"insertReadingOrderEntry": `INSERT INTO readingOrderEntry (orderID, position, issueUUID)
+SELECT ?, ?, uuid
+FROM issue
+WHERE uuid = ?;`,
+	// add an issue, which must exist, after the last of a reading order;
+	// parameters are the order and issue
+	"appendReadingOrderEntry": `INSERT INTO readingOrderEntry (orderID, position, issueUUID)
+SELECT
+	?1,
+	(SELECT COALESCE(MAX(position) + 1, 0) FROM readingOrderEntry WHERE orderID = ?1),
+	uuid
+FROM issue
+WHERE uuid = ?2;`,
+	// whether an issue is in a reading order; parameters are the order and
+	// issue
+	"selectInReadingOrder": `SELECT EXISTS (
+	SELECT 1
+	FROM readingOrderEntry
+	WHERE orderID = ?
+		AND issueUUID = ?
+);`,
+	// whether a reading order exists
+	"selectReadingOrderExists": `SELECT EXISTS (
+	SELECT 1
+	FROM readingOrder
+	WHERE id = ?
+);`,
+	// the issues of a reading order in order
+	"selectReadingOrderIssues": `SELECT
+	i.uuid,
+	i.title,
+	i.description,
+	i.publisher,
+	i.imprint,
+	i.issueNumber,
+	i.pages,
+	i.publicationDate,
+	i.url,
+	i.dateRemoved,
+	i.subscription,
+	COALESCE(i.toAdd, ''),
+	s.uuid,
+	s.title,
+	s.description,
+	s.bookCount,
+	s.issueCount,
+	s.volumeCount,
+	s.omnibusCount,
+	s.url,
+	s.dateUpdated,
+	s.dateRemoved
+FROM readingOrderEntry e
+	JOIN issue i ON i.uuid = e.issueUUID
+	JOIN series s ON s.uuid = i.seriesUUID
+WHERE e.orderID = ?
+ORDER BY e.position;`,
+	// the issues of a reading order in order, as exported, numbered from 1
+	"exportReadingOrder": `SELECT
+	e.position + 1,
+	i.uuid,
+	s.title,
+	i.issueNumber,
+	i.title,
+	i.publicationDate,
+	i.printRelease,
+	i.url,
+	i.dateRemoved
+FROM readingOrderEntry e
+	JOIN issue i ON i.uuid = e.issueUUID
+	JOIN series s ON s.uuid = i.seriesUUID
+WHERE e.orderID = ?
+ORDER BY e.position;`,
+	// issue plan changes made from the first date to before the second, with
+	// their issues, newest first
+	"selectPlanChanges": `SELECT
+	i.uuid,
+	i.title,
+	i.description,
+	i.publisher,
+	i.imprint,
+	i.issueNumber,
+	i.pages,
+	i.publicationDate,
+	i.url,
+	i.dateRemoved,
+	i.subscription,
+	COALESCE(i.toAdd, ''),
+	s.uuid,
+	s.title,
+	s.description,
+	s.bookCount,
+	s.issueCount,
+	s.volumeCount,
+	s.omnibusCount,
+	s.url,
+	s.dateUpdated,
+	s.dateRemoved,
+	pc.runID,
+	pc.oldPlans,
+	pc.newPlans,
+	pc.dateChanged
+FROM issuePlanChange pc
+	JOIN issue i ON i.uuid = pc.issueUUID
+	JOIN series s ON s.uuid = i.seriesUUID
+WHERE pc.dateChanged >= ?
+	AND pc.dateChanged < ?
+ORDER BY
+	pc.dateChanged DESC,
+	pc.id DESC;`,
+	// fallback LIKE search of series and issues for builds without FTS5; the
+	// first %s is replaced by the conditions for every term to be in the
+	// title, the others by those for every term to be in the title or
+	// description, each preceded by whether to include those removed from
+	// DCUI
+	"searchLike": `SELECT
+	*,
+	CASE WHEN %s THEN 0 ELSE 1 END AS rank
+FROM (
+	SELECT
+		'series' AS kind,
+		uuid,
+		title,
+		url,
+		'' AS seriesUUID,
+		'' AS seriesTitle,
+		description,
+		dateRemoved <> 0 AS removed
+	FROM series
+	WHERE (? OR dateRemoved = 0)
+		AND %s
+	UNION ALL
+	SELECT
+		'issue',
+		i.uuid,
+		i.title,
+		i.url,
+		i.seriesUUID,
+		s.title,
+		i.description,
+		i.dateRemoved <> 0
+	FROM issue i
+		JOIN series s ON s.uuid = i.seriesUUID
+	WHERE (? OR i.dateRemoved = 0)
+		AND %s
+)
+ORDER BY
+	rank,
+	title COLLATE NOCASE,
+	uuid
+LIMIT ?;`,
 }
 
-var templates = map[string]string{
+// statements are prepared once per refresh and executed with bound
+// parameters.
+//
+//nolint:gochecknoglobals
+var statements = map[string]string{
 	// upsert series.
 	"upsertSeries": `INSERT INTO series (
 	uuid,
@@ -95,29 +960,158 @@ var templates = map[string]string{
 	issueCount,
 	volumeCount,
 	omnibusCount,
-	url)
+	url,
+	lastSeenRun)
 VALUES
-    %v
+	(?, ?, '', ?, ?, ?, ?, ?, ?)
 ON CONFLICT DO UPDATE SET
 	title = excluded.title,
-	description = excluded.description,
 	bookCount = excluded.bookCount,
 	issueCount = excluded.issueCount,
 	volumeCount = excluded.volumeCount,
 	omnibusCount = excluded.omnibusCount,
 	url = excluded.url,
+	lastSeenRun = excluded.lastSeenRun,
+	dateRemoved = 0,
 	needUpdate = CASE
+		WHEN needUpdate = 1 THEN 1
+		WHEN dateRemoved > 0 THEN 1
 		WHEN bookCount <> excluded.bookCount THEN 1
-		WHEN dateUpdated < %v THEN 1
+		WHEN dateUpdated < ? THEN 1
 		ELSE 0
 	END;`,
+	// record a series about to be inserted for the first time; parameters
+	// are the run, uuid, title, date and uuid again
+	"recordNewSeries": `INSERT INTO seriesChange (runID, seriesUUID, kind, oldValue, newValue, dateChanged)
+SELECT ?, ?, 'new', '', ?, ?
+WHERE NOT EXISTS (
+	SELECT 1
+	FROM series
+	WHERE uuid = ?
+);`,
+	// record a removed series about to be restored; parameters are the run,
+	// date and uuid
+	"recordRestoredSeries": `INSERT INTO seriesChange (runID, seriesUUID, kind, oldValue, newValue, dateChanged)
+SELECT ?, uuid, 'restored', '', title, ?
+FROM series
+WHERE uuid = ?
+	AND dateRemoved > 0;`,
+	// record a series about to gain books; parameters are the run, new book
+	// count, date, uuid and new book count again
+	"recordBookCountIncrease": `INSERT INTO seriesChange (runID, seriesUUID, kind, oldValue, newValue, dateChanged)
+SELECT ?, uuid, 'bookCountIncreased', bookCount, ?, ?
+FROM series
+WHERE uuid = ?
+	AND bookCount < ?;`,
+	// record a series description about to change, unless it has never
+	// been downloaded; parameters are the run, new description, date, uuid
+	// and new description again
+	"recordDescriptionChange": `INSERT INTO seriesChange (runID, seriesUUID, kind, oldValue, newValue, dateChanged)
+SELECT ?, uuid, 'descriptionChanged', description, ?, ?
+FROM series
+WHERE uuid = ?
+	AND dateUpdated > 0
+	AND description <> ?;`,
+	// record the series not seen by a run that has stored every page of
+	// series, unless their removal has been recorded since they were last
+	// seen; parameters are the run and date. A run that saw no series at all
+	// is taken to be a fault in the search engine rather than an empty
+	// catalog, and records nothing.
+	"recordRemovedSeries": `INSERT INTO seriesChange (runID, seriesUUID, kind, oldValue, newValue, dateChanged)
+SELECT ?1, uuid, 'removed', title, '', ?2
+FROM series s
+WHERE lastSeenRun <> ?1
+	AND NOT EXISTS (
+		SELECT 1
+		FROM seriesChange c
+		WHERE c.seriesUUID = s.uuid
+			AND c.kind = 'removed'
+			AND c.runID > s.lastSeenRun
+	)
+	AND EXISTS (
+		SELECT 1
+		FROM series
+		WHERE lastSeenRun = ?1
+	);`,
+	// mark the series not seen by a run that has stored every page of series
+	// as removed; parameters are the run and date. Like recordRemovedSeries,
+	// a run that saw no series marks nothing.
+	"markRemovedSeries": `UPDATE series
+SET dateRemoved = ?2
+WHERE lastSeenRun <> ?1
+	AND dateRemoved = 0
+	AND EXISTS (
+		SELECT 1
+		FROM series
+		WHERE lastSeenRun = ?1
+	);`,
+	// mark the issues of removed series as removed
+	"markRemovedSeriesIssues": `UPDATE issue
+SET dateRemoved = ?
+WHERE dateRemoved = 0
+	AND seriesUUID IN (
+		SELECT uuid
+		FROM series
+		WHERE dateRemoved > 0
+	);`,
+	// mark the issues of a series not among its books as downloaded by a run
+	// as removed; parameters are the date, series and run
+	"markRemovedIssues": `UPDATE issue
+SET dateRemoved = ?
+WHERE seriesUUID = ?
+	AND lastSeenRun <> ?
+	AND dateRemoved = 0;`,
+	// alert that an issue first stored by a run matches the watchlist,
+	// naming the earliest addition to the watchlist that it matches, ignoring
+	// case through the collation of watch.value; parameters are the run, date
+	// and issue
+	"recordIssueAlert": `INSERT INTO alert (issueUUID, runID, watchKind, watchValue, dateCreated)
+SELECT i.uuid, ?1, w.kind, w.value, ?2
+FROM issue i
+	JOIN watch w ON (w.kind = 'series' AND w.value = i.seriesUUID)
+		OR (w.kind = 'creator' AND EXISTS (
+			SELECT 1
+			FROM issueCreator c
+			WHERE c.uuid = i.uuid
+				AND (w.value = c.name OR w.value = c.displayName)
+		))
+		OR (w.kind = 'genre' AND EXISTS (
+			SELECT 1
+			FROM seriesGenre g
+			WHERE g.uuid = i.seriesUUID
+				AND w.value = g.genre
+		))
+		OR (w.kind = 'imprint' AND (w.value = i.imprint OR EXISTS (
+			SELECT 1
+			FROM seriesImprint m
+			WHERE m.uuid = i.seriesUUID
+				AND w.value = m.imprint
+		)))
+WHERE i.uuid = ?3
+	AND i.firstSeenRun = ?1
+ORDER BY w.rowid
+LIMIT 1
+ON CONFLICT DO NOTHING;`,
+	// flag every series for a full refresh.
+	"flagAllSeries": `UPDATE series
+SET needUpdate = 1;`,
+	// update series description.
+	"updateSeriesDescription": `UPDATE series
+SET description = ?
+WHERE uuid = ?;`,
+	// mark series as updated.
+	"setSeriesUpdated": `UPDATE series
+SET
+	dateUpdated = ?,
+	needUpdate = 0
+WHERE uuid = ?;`,
 	// upsert seriesGenre.
 	"upsertSeriesGenre": `INSERT INTO seriesGenre (
 	uuid,
 	genre
 )
 VALUES
-	%v
+	(?, ?)
 ON CONFLICT DO NOTHING;`,
 	// upsert seriesImprint.
 	"upsertSeriesImprint": `INSERT INTO seriesImprint (
@@ -125,6 +1119,155 @@ ON CONFLICT DO NOTHING;`,
 	imprint
 )
 VALUES
-	%v
+	(?, ?)
+ON CONFLICT DO NOTHING;`,
+	// record that an issue's plans have changed since they were last stored;
+	// the parameters are the run, date, issue and new plans
+	"recordPlanChange": `INSERT INTO issuePlanChange (runID, issueUUID, oldPlans, newPlans, dateChanged)
+SELECT ?1, uuid, subscription, ?4, ?2
+FROM issue
+WHERE uuid = ?3
+	AND plansSeen = 1
+	AND subscription <> ?4;`,
+	// upsert issue.
+	"upsertIssue": `INSERT INTO issue (
+	uuid,
+	seriesUUID,
+	title,
+	description,
+	publisher,
+	imprint,
+	issueNumber,
+	pages,
+	publicationDate,
+	url,
+	subscription,
+	toAdd,
+	plansSeen,
+	lastSeenRun,
+	firstSeenRun,
+	printRelease)
+VALUES
+	(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?)
+ON CONFLICT DO UPDATE SET
+	seriesUUID = excluded.seriesUUID,
+	title = excluded.title,
+	description = excluded.description,
+	publisher = excluded.publisher,
+	imprint = excluded.imprint,
+	issueNumber = excluded.issueNumber,
+	pages = excluded.pages,
+	publicationDate = excluded.publicationDate,
+	url = excluded.url,
+	subscription = excluded.subscription,
+	toAdd = excluded.toAdd,
+	plansSeen = 1,
+	lastSeenRun = excluded.lastSeenRun,
+	printRelease = excluded.printRelease,
+	dateRemoved = 0;`,
+	// upsert issueTag.
+	"upsertIssueTag": `INSERT INTO issueTag (
+	uuid,
+	category,
+	name
+)
+VALUES
+	(?, ?, ?)
+ON CONFLICT DO NOTHING;`,
+	// upsert issueCreator.
+	"upsertIssueCreator": `INSERT INTO issueCreator (
+	uuid,
+	type,
+	name,
+	displayName
+)
+VALUES
+	(?, ?, ?, ?)
 ON CONFLICT DO NOTHING;`,
+	// create a creator for a name unless the name, or a variant of it with
+	// the same display name ignoring case, spaces, periods and hyphens, is
+	// known.
+	"insertCreator": `INSERT INTO creator (displayName)
+SELECT ?
+WHERE NOT EXISTS (
+	SELECT 1
+	FROM creatorAlias
+	WHERE name = ?
+		OR nameKey = lower(replace(replace(replace(?, ' ', ''), '.', ''), '-', ''))
+);`,
+	// file a name under the creator of its variants, or the creator given.
+	"insertCreatorAlias": `INSERT INTO creatorAlias (name, nameKey, creatorID)
+SELECT
+	?,
+	k.nameKey,
+	COALESCE((
+		SELECT creatorID
+		FROM creatorAlias
+		WHERE nameKey = k.nameKey
+		ORDER BY creatorID
+		LIMIT 1
+	), ?)
+FROM (
+	SELECT lower(replace(replace(replace(?, ' ', ''), '.', ''), '-', '')) AS nameKey
+) k
+WHERE true
+ON CONFLICT DO NOTHING;`,
+	// record the last search page stored by a refresh.
+	"checkpointPage": `UPDATE refreshRun
+SET page = ?
+WHERE id = ?;`,
+	// record the phase a refresh has reached.
+	"checkpointPhase": `UPDATE refreshRun
+SET phase = ?
+WHERE id = ?;`,
+	// record the last series updated by a refresh.
+	"checkpointSeries": `UPDATE refreshRun
+SET lastSeriesUUID = ?
+WHERE id = ?;`,
+	// mark a refresh as finished.
+	"finishRun": `UPDATE refreshRun
+SET
+	status = 'complete',
+	dateFinished = ?
+WHERE id = ?;`,
+}
+
+// searchStatements keep the full-text index in step with the catalog. They
+// are prepared alongside statements only if SQLite was built with FTS5.
+//
+//nolint:gochecknoglobals
+var searchStatements = map[string]string{
+	// add a document to the index if it is not there already
+	"insertSearchDocument": `INSERT INTO searchDocument (kind, uuid)
+VALUES
+	(?, ?)
+ON CONFLICT DO NOTHING;`,
+	// remove a document's entry from the index
+	"deleteSearchEntry": `DELETE FROM searchIndex
+WHERE rowid = (
+	SELECT id
+	FROM searchDocument
+	WHERE kind = ?
+		AND uuid = ?
+);`,
+	// index a series as it is stored
+	"indexSeries": `INSERT INTO searchIndex (rowid, title, description)
+SELECT
+	d.id,
+	s.title,
+	s.description
+FROM searchDocument d
+	JOIN series s ON s.uuid = d.uuid
+WHERE d.kind = 'series'
+	AND d.uuid = ?;`,
+	// index an issue as it is stored
+	"indexIssue": `INSERT INTO searchIndex (rowid, title, description)
+SELECT
+	d.id,
+	i.title,
+	i.description
+FROM searchDocument d
+	JOIN issue i ON i.uuid = d.uuid
+WHERE d.kind = 'issue'
+	AND d.uuid = ?;`,
 }
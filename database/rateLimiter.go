@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket holding up to burst tokens, refilled at rate
+// tokens per second. Each request takes one token, waiting for it if the
+// bucket is empty.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a full bucket. A rate of zero or less disables
+// limiting.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	burst = max(burst, 1)
+
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes a token, blocking until one is available or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	// Take the token now, even if it has not been refilled yet, so that
+	// concurrent callers queue up behind each other.
+	l.tokens--
+
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	err := sleep(ctx, delay)
+	if err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(100, 2)
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+
+	// The first two requests use the burst; the other eight are spread out
+	// at 10ms intervals between all the goroutines.
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := limiter.wait(context.Background())
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 75*time.Millisecond {
+		t.Errorf("10 requests took %v, want at least 80ms", elapsed)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	limiter := newRateLimiter(0.1, 1)
+
+	err := limiter.wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = limiter.wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wait error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := newRateLimiter(0, 1)
+
+	for range 100 {
+		err := limiter.wait(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
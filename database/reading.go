@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxRating is the highest rating an issue can be given.
+const MaxRating = 5
+
+var (
+	errUnknownIssue  = errors.New("no such issue")
+	errUnknownSeries = errors.New("no such series")
+	errRating        = fmt.Errorf("rating must be from 0 to %v", MaxRating)
+)
+
+// Reading is what the user has recorded about reading an issue.
+type Reading struct {
+	Read bool
+	// DateRead is when the issue was read, or the zero time if it has not
+	// been or the date is not known.
+	DateRead time.Time
+	// Rating is from 1 to MaxRating, or 0 if the issue has not been rated.
+	Rating int
+	Notes  string
+}
+
+// Reading returns what has been recorded about reading the issue, which is
+// nothing if the issue is unknown.
+func (db Database) Reading(ctx context.Context, issueUUID string) (Reading, error) {
+	var (
+		r        Reading
+		dateRead int64
+	)
+
+	err := db.database.QueryRowContext(ctx, queries["selectReading"], issueUUID).Scan(
+		&r.Read, &dateRead, &r.Rating, &r.Notes)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		err = fmt.Errorf("database.Reading: %w", err)
+		db.log.Println(err)
+
+		return r, err
+	}
+
+	r.DateRead = unixTime(dateRead)
+
+	return r, nil
+}
+
+// SetRead records whether the issue has been read, and if so when. A zero
+// date records it as read without a date.
+func (db Database) SetRead(ctx context.Context, issueUUID string, read bool, date time.Time) error {
+	db.log.Printf("marking issue %v read: %v\n", issueUUID, read)
+
+	var dateRead int64
+	if read && !date.IsZero() {
+		dateRead = date.Unix()
+	}
+
+	err := db.execForIssue(ctx, queries["upsertReadStatus"], read, dateRead, issueUUID)
+	if err != nil {
+		err = fmt.Errorf("database.SetRead: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// SetRating rates the issue from 1 to MaxRating, or clears its rating if
+// rating is 0.
+func (db Database) SetRating(ctx context.Context, issueUUID string, rating int) error {
+	db.log.Printf("rating issue %v %v\n", issueUUID, rating)
+
+	if rating < 0 || rating > MaxRating {
+		err := fmt.Errorf("database.SetRating: %w: %v", errRating, rating)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err := db.execForIssue(ctx, queries["upsertRating"], rating, issueUUID)
+	if err != nil {
+		err = fmt.Errorf("database.SetRating: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// SetNotes replaces the notes on the issue.
+func (db Database) SetNotes(ctx context.Context, issueUUID, notes string) error {
+	db.log.Printf("noting issue %v\n", issueUUID)
+
+	err := db.execForIssue(ctx, queries["upsertNotes"], notes, issueUUID)
+	if err != nil {
+		err = fmt.Errorf("database.SetNotes: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// execForIssue runs a query that records something about an issue, failing
+// if the issue is unknown.
+func (db Database) execForIssue(ctx context.Context, query string, args ...any) error {
+	result, err := db.database.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("database.execForIssue: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("database.execForIssue: %w", err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("database.execForIssue: %w: %v", errUnknownIssue, args[len(args)-1])
+	}
+
+	return nil
+}
+
+// Follow starts or stops following the series.
+func (db Database) Follow(ctx context.Context, seriesUUID string, follow bool) error {
+	db.log.Printf("following series %v: %v\n", seriesUUID, follow)
+
+	if !follow {
+		_, err := db.database.ExecContext(ctx, queries["deleteFollow"], seriesUUID)
+		if err != nil {
+			err = fmt.Errorf("database.Follow: %w", err)
+			db.log.Println(err)
+
+			return err
+		}
+
+		return nil
+	}
+
+	_, err := db.database.ExecContext(ctx, queries["insertFollow"], time.Now().Unix(), seriesUUID)
+	if err != nil {
+		err = fmt.Errorf("database.Follow: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	following, err := db.Following(ctx, seriesUUID)
+	if err == nil && !following {
+		err = fmt.Errorf("%w: %v", errUnknownSeries, seriesUUID)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("database.Follow: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// Following reports whether the series is followed.
+func (db Database) Following(ctx context.Context, seriesUUID string) (bool, error) {
+	var following bool
+
+	err := db.database.QueryRowContext(ctx, queries["selectFollowing"], seriesUUID).Scan(&following)
+	if err != nil {
+		err = fmt.Errorf("database.Following: %w", err)
+		db.log.Println(err)
+
+		return false, err
+	}
+
+	return following, nil
+}
+
+// FollowedSeries returns the followed series in title order, including any
+// removed from DCUI.
+func (db Database) FollowedSeries(ctx context.Context) ([]Series, error) {
+	rows, err := db.database.QueryContext(ctx, queries["selectFollowedSeries"])
+	if err != nil {
+		err = fmt.Errorf("database.FollowedSeries: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	series, err := scanSeries(rows)
+	if err != nil {
+		err = fmt.Errorf("database.FollowedSeries: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// ContinueReading returns the next issue to read in each followed series: the
+// unread issue with the lowest issue number. Series are in title order, and
+// those with nothing left to read are left out.
+func (db Database) ContinueReading(ctx context.Context) ([]Issue, error) {
+	issues, err := db.selectIssues(ctx, queries["selectContinueReading"])
+	if err != nil {
+		err = fmt.Errorf("database.ContinueReading: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// UnreadInFollowed returns every unread issue of the followed series, by
+// series title and then issue number.
+func (db Database) UnreadInFollowed(ctx context.Context) ([]Issue, error) {
+	issues, err := db.selectIssues(ctx, queries["selectUnreadFollowed"])
+	if err != nil {
+		err = fmt.Errorf("database.UnreadInFollowed: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// selectIssues returns the issues selected by query, which selects the
+// columns read by scanIssues.
+func (db Database) selectIssues(ctx context.Context, query string, args ...any) ([]Issue, error) {
+	rows, err := db.database.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("database.selectIssues: %w", err)
+	}
+	defer rows.Close()
+
+	issues, err := scanIssues(rows)
+	if err != nil {
+		return nil, fmt.Errorf("database.selectIssues: %w", err)
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,631 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errUnknownReadingOrder = errors.New("no such reading order")
+	errReadingOrderName    = errors.New("reading orders need a name")
+	errDuplicateIssue      = errors.New("issue is already in the reading order")
+	errNoTaggedIssues      = errors.New("no issues have the tag")
+)
+
+// TagGroup is a tag with a category, such as an event or storyline, that
+// issues can be grouped into a reading order by.
+type TagGroup struct {
+	Category string
+	Name     string
+	// Issues counts the issues still on DCUI with the tag.
+	Issues int
+}
+
+// ReadingOrder is a named list of issues in the order they should be read.
+// Orders are built from a tag by BuildReadingOrder or made by hand with
+// CreateReadingOrder, and either kind can be edited.
+type ReadingOrder struct {
+	ID   int64
+	Name string
+	// Tag is the tag the order was built from, and empty for orders made by
+	// hand.
+	Tag     TagGroup
+	Issues  int
+	Created time.Time
+	Updated time.Time
+}
+
+// TagGroups returns the tags with a category, such as events and storylines,
+// in name order.
+func (db Database) TagGroups(ctx context.Context) ([]TagGroup, error) {
+	rows, err := db.database.QueryContext(ctx, queries["selectTagGroups"])
+	if err != nil {
+		err = fmt.Errorf("database.TagGroups: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []TagGroup
+
+	for rows.Next() {
+		var g TagGroup
+
+		err = rows.Scan(&g.Category, &g.Name, &g.Issues)
+		if err != nil {
+			err = fmt.Errorf("database.TagGroups: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+
+		groups = append(groups, g)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("database.TagGroups: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// ReadingOrders returns every reading order in name order.
+func (db Database) ReadingOrders(ctx context.Context) ([]ReadingOrder, error) {
+	rows, err := db.database.QueryContext(ctx, queries["selectReadingOrders"])
+	if err != nil {
+		err = fmt.Errorf("database.ReadingOrders: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []ReadingOrder
+
+	for rows.Next() {
+		var (
+			o                ReadingOrder
+			created, updated int64
+		)
+
+		err = rows.Scan(&o.ID, &o.Name, &o.Tag.Category, &o.Tag.Name, &created, &updated, &o.Issues)
+		if err != nil {
+			err = fmt.Errorf("database.ReadingOrders: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+
+		o.Created = unixTime(created)
+		o.Updated = unixTime(updated)
+		orders = append(orders, o)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("database.ReadingOrders: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// BuildReadingOrder builds a reading order from the issues still on DCUI with
+// the tag, in the order they were released in print, or published on DCUI if
+// their print release is unknown, and returns its ID. An order already built
+// from the tag is rebuilt, losing any changes made to it by hand.
+func (db Database) BuildReadingOrder(ctx context.Context, category, name string) (int64, error) {
+	db.log.Printf("building reading order for %v %q\n", category, name)
+
+	tx, err := db.database.BeginTx(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("database.BuildReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+
+	id, err := buildReadingOrder(ctx, tx, category, name)
+	if err != nil {
+		_ = tx.Rollback()
+
+		err = fmt.Errorf("database.BuildReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = fmt.Errorf("database.BuildReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// buildReadingOrder builds the reading order for a tag in tx.
+func buildReadingOrder(ctx context.Context, tx *sql.Tx, category, name string) (int64, error) {
+	uuids, err := taggedIssueUUIDs(ctx, tx, category, name)
+	if err != nil {
+		return 0, fmt.Errorf("database.buildReadingOrder: %w", err)
+	}
+
+	if len(uuids) == 0 {
+		return 0, fmt.Errorf("database.buildReadingOrder: %w: %v %q", errNoTaggedIssues, category, name)
+	}
+
+	var id int64
+
+	err = tx.QueryRowContext(ctx, queries["selectTagReadingOrder"], category, name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		id, err = insertReadingOrder(ctx, tx, name, category, name)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("database.buildReadingOrder: %w", err)
+	}
+
+	err = writeReadingOrder(ctx, tx, id, uuids)
+	if err != nil {
+		return 0, fmt.Errorf("database.buildReadingOrder: %w", err)
+	}
+
+	return id, nil
+}
+
+// taggedIssueUUIDs returns the UUIDs of the issues still on DCUI with a tag,
+// in reading order.
+func taggedIssueUUIDs(ctx context.Context, tx *sql.Tx, category, name string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, queries["selectTaggedIssueUUIDs"], category, name)
+	if err != nil {
+		return nil, fmt.Errorf("database.taggedIssueUUIDs: %w", err)
+	}
+	defer rows.Close()
+
+	var uuids []string
+
+	for rows.Next() {
+		var uuid string
+
+		err = rows.Scan(&uuid)
+		if err != nil {
+			return nil, fmt.Errorf("database.taggedIssueUUIDs: %w", err)
+		}
+
+		uuids = append(uuids, uuid)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("database.taggedIssueUUIDs: %w", err)
+	}
+
+	return uuids, nil
+}
+
+// CreateReadingOrder creates an empty reading order and returns its ID.
+func (db Database) CreateReadingOrder(ctx context.Context, name string) (int64, error) {
+	db.log.Printf("creating reading order %q\n", name)
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		err := fmt.Errorf("database.CreateReadingOrder: %w", errReadingOrderName)
+		db.log.Println(err)
+
+		return 0, err
+	}
+
+	tx, err := db.database.BeginTx(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("database.CreateReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+
+	id, err := insertReadingOrder(ctx, tx, name, "", "")
+	if err != nil {
+		_ = tx.Rollback()
+
+		err = fmt.Errorf("database.CreateReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = fmt.Errorf("database.CreateReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// insertReadingOrder creates an empty reading order in tx and returns its ID.
+func insertReadingOrder(ctx context.Context, tx *sql.Tx, name, tagCategory, tagName string) (int64, error) {
+	now := time.Now().Unix()
+
+	result, err := tx.ExecContext(ctx, queries["insertReadingOrder"], name, tagCategory, tagName, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("database.insertReadingOrder: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("database.insertReadingOrder: %w", err)
+	}
+
+	return id, nil
+}
+
+// RenameReadingOrder renames a reading order.
+func (db Database) RenameReadingOrder(ctx context.Context, id int64, name string) error {
+	db.log.Printf("renaming reading order %v to %q\n", id, name)
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		err := fmt.Errorf("database.RenameReadingOrder: %w", errReadingOrderName)
+		db.log.Println(err)
+
+		return err
+	}
+
+	result, err := db.database.ExecContext(ctx, queries["renameReadingOrder"], name, time.Now().Unix(), id)
+	if err == nil {
+		err = readingOrderChanged(result, id)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("database.RenameReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// DeleteReadingOrder deletes a reading order, which must exist.
+func (db Database) DeleteReadingOrder(ctx context.Context, id int64) error {
+	db.log.Printf("deleting reading order %v\n", id)
+
+	tx, err := db.database.BeginTx(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("database.DeleteReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, queries["deleteReadingOrderEntries"], id)
+	if err == nil {
+		var result sql.Result
+
+		result, err = tx.ExecContext(ctx, queries["deleteReadingOrder"], id)
+		if err == nil {
+			err = readingOrderChanged(result, id)
+		}
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+
+		err = fmt.Errorf("database.DeleteReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = fmt.Errorf("database.DeleteReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// ReadingOrderIssues returns the issues of a reading order in order.
+func (db Database) ReadingOrderIssues(ctx context.Context, id int64) ([]Issue, error) {
+	err := db.findReadingOrder(ctx, id)
+	if err != nil {
+		err = fmt.Errorf("database.ReadingOrderIssues: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	issues, err := db.selectIssues(ctx, queries["selectReadingOrderIssues"], id)
+	if err != nil {
+		err = fmt.Errorf("database.ReadingOrderIssues: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// SetReadingOrderIssues replaces the issues of a reading order with those with
+// the UUIDs, in order. Each issue can only be in an order once.
+func (db Database) SetReadingOrderIssues(ctx context.Context, id int64, issueUUIDs []string) error {
+	db.log.Printf("setting the %v issues of reading order %v\n", len(issueUUIDs), id)
+
+	tx, err := db.database.BeginTx(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("database.SetReadingOrderIssues: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err = writeReadingOrder(ctx, tx, id, issueUUIDs)
+	if err != nil {
+		_ = tx.Rollback()
+
+		err = fmt.Errorf("database.SetReadingOrderIssues: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = fmt.Errorf("database.SetReadingOrderIssues: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// AddToReadingOrder adds an issue to the end of a reading order.
+func (db Database) AddToReadingOrder(ctx context.Context, id int64, issueUUID string) error {
+	db.log.Printf("adding issue %v to reading order %v\n", issueUUID, id)
+
+	tx, err := db.database.BeginTx(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("database.AddToReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err = appendToReadingOrder(ctx, tx, id, issueUUID)
+	if err != nil {
+		_ = tx.Rollback()
+
+		err = fmt.Errorf("database.AddToReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		err = fmt.Errorf("database.AddToReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// appendToReadingOrder adds an issue after the last of a reading order in tx.
+// The order is touched first so that tx holds the write lock while it works
+// out the position.
+func appendToReadingOrder(ctx context.Context, tx *sql.Tx, id int64, issueUUID string) error {
+	result, err := tx.ExecContext(ctx, queries["touchReadingOrder"], time.Now().Unix(), id)
+	if err == nil {
+		err = readingOrderChanged(result, id)
+	}
+
+	var present bool
+
+	if err == nil {
+		err = tx.QueryRowContext(ctx, queries["selectInReadingOrder"], id, issueUUID).Scan(&present)
+	}
+
+	if err == nil && present {
+		err = fmt.Errorf("%w: %v", errDuplicateIssue, issueUUID)
+	}
+
+	if err == nil {
+		result, err = tx.ExecContext(ctx, queries["appendReadingOrderEntry"], id, issueUUID)
+	}
+
+	var n int64
+
+	if err == nil {
+		n, err = result.RowsAffected()
+	}
+
+	if err == nil && n == 0 {
+		err = fmt.Errorf("%w: %v", errUnknownIssue, issueUUID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("database.appendToReadingOrder: %w", err)
+	}
+
+	return nil
+}
+
+// writeReadingOrder replaces the issues of a reading order in tx, failing if
+// the order or any of the issues is unknown.
+func writeReadingOrder(ctx context.Context, tx *sql.Tx, id int64, issueUUIDs []string) error {
+	result, err := tx.ExecContext(ctx, queries["touchReadingOrder"], time.Now().Unix(), id)
+	if err == nil {
+		err = readingOrderChanged(result, id)
+	}
+
+	if err == nil {
+		_, err = tx.ExecContext(ctx, queries["deleteReadingOrderEntries"], id)
+	}
+
+	if err != nil {
+		return fmt.Errorf("database.writeReadingOrder: %w", err)
+	}
+
+	seen := map[string]bool{}
+
+	for position, uuid := range issueUUIDs {
+		if seen[uuid] {
+			return fmt.Errorf("database.writeReadingOrder: %w: %v", errDuplicateIssue, uuid)
+		}
+
+		seen[uuid] = true
+
+		result, err := tx.ExecContext(ctx, queries["insertReadingOrderEntry"], id, position, uuid)
+		if err != nil {
+			return fmt.Errorf("database.writeReadingOrder: %w", err)
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("database.writeReadingOrder: %w", err)
+		}
+
+		if n == 0 {
+			return fmt.Errorf("database.writeReadingOrder: %w: %v", errUnknownIssue, uuid)
+		}
+	}
+
+	return nil
+}
+
+// findReadingOrder returns an error if there is no reading order with the ID.
+func (db Database) findReadingOrder(ctx context.Context, id int64) error {
+	var exists bool
+
+	err := db.database.QueryRowContext(ctx, queries["selectReadingOrderExists"], id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("database.findReadingOrder: %w", err)
+	}
+
+	if !exists {
+		return fmt.Errorf("database.findReadingOrder: %w: %v", errUnknownReadingOrder, id)
+	}
+
+	return nil
+}
+
+// readingOrderChanged returns an error if result shows that no reading order
+// with the ID was changed.
+func readingOrderChanged(result sql.Result, id int64) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("database.readingOrderChanged: %w", err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("database.readingOrderChanged: %w: %v", errUnknownReadingOrder, id)
+	}
+
+	return nil
+}
+
+// ExportedReadingOrderEntry is an issue of a reading order as written by
+// ExportReadingOrder.
+type ExportedReadingOrderEntry struct {
+	Position        int    `json:"position"`
+	UUID            string `json:"uuid"`
+	SeriesTitle     string `json:"seriesTitle"`
+	IssueNumber     string `json:"issueNumber"`
+	Title           string `json:"title"`
+	PublicationDate string `json:"publicationDate"`
+	PrintRelease    string `json:"printRelease"`
+	URL             string `json:"url"`
+	DateRemoved     string `json:"dateRemoved"`
+}
+
+func (ExportedReadingOrderEntry) csvHeader() []string {
+	return []string{"position", "uuid", "seriesTitle", "issueNumber", "title", "publicationDate", "printRelease",
+		"url", "dateRemoved"}
+}
+
+func (e ExportedReadingOrderEntry) csvRow() []string {
+	return []string{strconv.Itoa(e.Position), e.UUID, e.SeriesTitle, e.IssueNumber, e.Title, e.PublicationDate,
+		e.PrintRelease, e.URL, e.DateRemoved}
+}
+
+// ExportReadingOrder writes the issues of a reading order to w in order,
+// numbered from 1, and returns how many were written.
+func (db Database) ExportReadingOrder(ctx context.Context, w io.Writer, format ExportFormat, id int64) (int, error) {
+	db.log.Printf("exporting reading order %v as %v\n", id, format)
+
+	err := db.findReadingOrder(ctx, id)
+	if err != nil {
+		err = fmt.Errorf("database.ExportReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+
+	rows, err := db.database.QueryContext(ctx, queries["exportReadingOrder"], id)
+	if err != nil {
+		err = fmt.Errorf("database.ExportReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+	defer rows.Close()
+
+	n, err := export(rows, w, format, func(rows *sql.Rows) (ExportedReadingOrderEntry, error) {
+		var (
+			e                                          ExportedReadingOrderEntry
+			publicationDate, printRelease, dateRemoved int64
+		)
+
+		err := rows.Scan(&e.Position, &e.UUID, &e.SeriesTitle, &e.IssueNumber, &e.Title, &publicationDate,
+			&printRelease, &e.URL, &dateRemoved)
+		if err != nil {
+			return e, err
+		}
+
+		if publicationDate > 0 {
+			e.PublicationDate = time.Unix(publicationDate, 0).UTC().Format(time.DateOnly)
+		}
+
+		if printRelease > 0 {
+			e.PrintRelease = time.Unix(printRelease, 0).UTC().Format(time.DateOnly)
+		}
+
+		e.DateRemoved = exportedTime(dateRemoved)
+
+		return e, nil
+	})
+	if err != nil {
+		err = fmt.Errorf("database.ExportReadingOrder: %w", err)
+		db.log.Println(err)
+
+		return n, err
+	}
+
+	db.log.Printf("exported %v issues\n", n)
+
+	return n, nil
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadingOrders(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+	ctx := context.Background()
+
+	// Batman #2 was released in print before #1, which has no print release
+	// and so is ordered by its publication date.
+	fs.editFixture(t, "books", batmanUUID, func(fixture map[string]any) {
+		values := fixture["values"].([]any)
+		values[0].(map[string]any)["print_release"] = ""
+		values[1].(map[string]any)["print_release"] = "2016-06-01"
+	})
+
+	err := db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := db.TagGroups(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(tags) != "[{event Rebirth 2}]" {
+		t.Errorf("TagGroups = %v, want the Rebirth event", tags)
+	}
+
+	rebirth, err := db.BuildReadingOrder(ctx, "event", "Rebirth")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := db.ReadingOrderIssues(ctx, rebirth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "built order", issueTitles(issues), []string{"Batman (2016-) #2", "Batman (2016-) #1"})
+
+	const harleyIssueUUID = "b0000003-0000-4000-8000-000000000003"
+
+	err = db.AddToReadingOrder(ctx, rebirth, harleyIssueUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AddToReadingOrder(ctx, rebirth, batman9UUID)
+	if !errors.Is(err, errDuplicateIssue) {
+		t.Errorf("adding an issue twice returned %v, want %v", err, errDuplicateIssue)
+	}
+
+	err = db.AddToReadingOrder(ctx, rebirth, "missing")
+	if !errors.Is(err, errUnknownIssue) {
+		t.Errorf("adding an unknown issue returned %v, want %v", err, errUnknownIssue)
+	}
+
+	_, err = db.BuildReadingOrder(ctx, "event", "Flashpoint")
+	if !errors.Is(err, errNoTaggedIssues) {
+		t.Errorf("building an order for an unused tag returned %v, want %v", err, errNoTaggedIssues)
+	}
+
+	_, err = db.CreateReadingOrder(ctx, " ")
+	if !errors.Is(err, errReadingOrderName) {
+		t.Errorf("creating an unnamed order returned %v, want %v", err, errReadingOrderName)
+	}
+
+	mine, err := db.CreateReadingOrder(ctx, "Mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.SetReadingOrderIssues(ctx, mine, []string{harleyIssueUUID, batman9UUID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orders, err := db.ReadingOrders(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, o := range orders {
+		got = append(got, fmt.Sprintf("%v %v %v", o.Name, o.Tag.Name, o.Issues))
+	}
+
+	assertStrings(t, "orders", got, []string{"Mine  2", "Rebirth Rebirth 3"})
+
+	// Rebuilding drops the issue added by hand.
+	rebuilt, err := db.BuildReadingOrder(ctx, "event", "Rebirth")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rebuilt != rebirth {
+		t.Errorf("rebuilding created order %v, want %v rebuilt", rebuilt, rebirth)
+	}
+
+	var buf bytes.Buffer
+
+	n, err := db.ExportReadingOrder(ctx, &buf, ExportCSV, rebirth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if n != 2 || len(lines) != 3 || !strings.HasPrefix(lines[1], "1,"+batman10UUID+",Batman (2016),2,") ||
+		!strings.Contains(lines[1], ",2016-07-06,2016-06-01,https://") {
+		t.Errorf("ExportReadingOrder wrote %v issues:\n%v", n, buf.String())
+	}
+
+	err = db.RenameReadingOrder(ctx, -1, "Nothing")
+	if !errors.Is(err, errUnknownReadingOrder) {
+		t.Errorf("renaming an unknown order returned %v, want %v", err, errUnknownReadingOrder)
+	}
+
+	err = db.AddToReadingOrder(ctx, -1, batman9UUID)
+	if !errors.Is(err, errUnknownReadingOrder) {
+		t.Errorf("adding to an unknown order returned %v, want %v", err, errUnknownReadingOrder)
+	}
+
+	_, err = db.ExportReadingOrder(ctx, &buf, ExportCSV, -1)
+	if !errors.Is(err, errUnknownReadingOrder) {
+		t.Errorf("exporting an unknown order returned %v, want %v", err, errUnknownReadingOrder)
+	}
+
+	_, err = db.ReadingOrderIssues(ctx, -1)
+	if !errors.Is(err, errUnknownReadingOrder) {
+		t.Errorf("listing an unknown order returned %v, want %v", err, errUnknownReadingOrder)
+	}
+
+	err = db.DeleteReadingOrder(ctx, mine)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "entries left", queryStrings(t, db, "SELECT DISTINCT orderID FROM readingOrderEntry"),
+		[]string{fmt.Sprint(rebirth)})
+
+	err = db.DeleteReadingOrder(ctx, mine)
+	if !errors.Is(err, errUnknownReadingOrder) {
+		t.Errorf("deleting a deleted order returned %v, want %v", err, errUnknownReadingOrder)
+	}
+}
+
+func TestAddToReadingOrderConcurrently(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+	ctx := context.Background()
+
+	err := db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := db.CreateReadingOrder(ctx, "Everything")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uuids := []string{batman9UUID, batman10UUID, "b0000003-0000-4000-8000-000000000003"}
+	errs := make(chan error, len(uuids))
+
+	for _, uuid := range uuids {
+		go func() {
+			errs <- db.AddToReadingOrder(ctx, id, uuid)
+		}()
+	}
+
+	for range uuids {
+		err := <-errs
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertStrings(t, "positions",
+		queryStrings(t, db, "SELECT DISTINCT position FROM readingOrderEntry WHERE orderID = ? ORDER BY position", id),
+		[]string{"0", "1", "2"})
+}
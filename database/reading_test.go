@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	batman9UUID  = "b0000001-0000-4000-8000-000000000001"
+	batman10UUID = "b0000002-0000-4000-8000-000000000002"
+)
+
+// issueTitles lists the titles of issues in order.
+func issueTitles(issues []Issue) []string {
+	var titles []string
+	for _, i := range issues {
+		titles = append(titles, i.Title)
+	}
+
+	return titles
+}
+
+func TestReadingList(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+	ctx := context.Background()
+
+	// Issue 10 sorts before issue 9 as text.
+	fs.editFixture(t, "books", batmanUUID, func(fixture map[string]any) {
+		values := fixture["values"].([]any)
+		values[0].(map[string]any)["issue_number"] = "9"
+		values[1].(map[string]any)["issue_number"] = "10"
+	})
+
+	err := db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := db.ContinueReading(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "continue reading before following", issueTitles(issues), nil)
+
+	for _, uuid := range []string{batmanUUID, harleyUUID} {
+		err = db.Follow(ctx, uuid, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = db.SetRead(ctx, batman9UUID, true, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err = db.ContinueReading(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "continue reading", issueTitles(issues), []string{
+		"Batman (2016-) #2",
+		"Harley Quinn's Greatest Hits",
+	})
+
+	err = db.SetRead(ctx, batman9UUID, false, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err = db.UnreadInFollowed(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "unread in followed series", issueTitles(issues), []string{
+		"Batman (2016-) #1",
+		"Batman (2016-) #2",
+		"Harley Quinn's Greatest Hits",
+	})
+
+	err = db.SetRating(ctx, batman10UUID, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.SetNotes(ctx, batman10UUID, "Better than 9.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.SetRead(ctx, batman10UUID, true, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reading, err := db.Reading(ctx, batman10UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reading != (Reading{Read: true, Rating: 4, Notes: "Better than 9."}) {
+		t.Errorf("Reading = %+v", reading)
+	}
+
+	err = db.SetRating(ctx, batman10UUID, MaxRating+1)
+	if !errors.Is(err, errRating) {
+		t.Errorf("SetRating(%v) returned %v, want %v", MaxRating+1, err, errRating)
+	}
+
+	err = db.SetRead(ctx, "missing", true, time.Time{})
+	if !errors.Is(err, errUnknownIssue) {
+		t.Errorf("SetRead of an unknown issue returned %v, want %v", err, errUnknownIssue)
+	}
+
+	err = db.Follow(ctx, "missing", true)
+	if !errors.Is(err, errUnknownSeries) {
+		t.Errorf("Follow of an unknown series returned %v, want %v", err, errUnknownSeries)
+	}
+
+	err = db.Follow(ctx, harleyUUID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	series, err := db.FollowedSeries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(series) != 1 || series[0].UUID != batmanUUID {
+		t.Errorf("FollowedSeries = %+v, want only Batman", series)
+	}
+}
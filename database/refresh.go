@@ -0,0 +1,1015 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RefreshOptions controls how RefreshDatabase updates the database.
+type RefreshOptions struct {
+	// Incremental only downloads descriptions and issues for series flagged
+	// with needUpdate. A full refresh downloads them for every series.
+	Incremental bool
+	// AllOrNothing runs the whole refresh in a single transaction, so either
+	// every change is committed or, if anything fails, none are. Otherwise
+	// each page of series and each updated series is committed on its own.
+	AllOrNothing bool
+	// Restart abandons any interrupted refresh instead of resuming it.
+	Restart bool
+	// Workers is how many series have their details downloaded at once.
+	// Values below 1 mean 1.
+	Workers int
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// refresher holds the state of a single RefreshDatabase run.
+type refresher struct {
+	db    Database
+	stmts preparedStatements
+	// tx is the transaction wrapping the whole run for all-or-nothing
+	// refreshes, and nil otherwise. txStmts are stmts bound to it.
+	tx      *sql.Tx
+	txStmts preparedStatements
+	// checkpoint is the run's progress, kept in step with the database.
+	checkpoint refreshRun
+	opts       RefreshOptions
+	// alerts is whether new issues matching the watchlist are alerted. It is
+	// false until a refresh has finished, as until then every issue is new.
+	alerts bool
+}
+
+// begin returns the transaction the next batch of changes should be written
+// in, together with the refresh's statements bound to it.
+func (r *refresher) begin() (*sql.Tx, preparedStatements, error) {
+	if r.tx != nil {
+		return r.tx, r.txStmts, nil
+	}
+
+	tx, err := r.db.database.Begin()
+	if err != nil {
+		err = fmt.Errorf("database.begin: %w", err)
+
+		return nil, nil, err
+	}
+
+	return tx, r.stmts.in(tx), nil
+}
+
+// commit commits a batch started with begin. For all-or-nothing refreshes
+// this is a no-op; the run's transaction is committed when the run finishes.
+func (r *refresher) commit(tx *sql.Tx) error {
+	if tx == r.tx {
+		return nil
+	}
+
+	err := tx.Commit()
+	if err != nil {
+		err = fmt.Errorf("database.commit: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// rollback abandons a batch started with begin. For all-or-nothing refreshes
+// the run's transaction is left for RefreshDatabase to roll back.
+func (r *refresher) rollback(tx *sql.Tx) {
+	if tx == r.tx {
+		return
+	}
+
+	_ = tx.Rollback()
+}
+
+// querier returns where reads during the refresh should go, so that they see
+// the refresh's own uncommitted changes.
+func (r *refresher) querier() querier {
+	if r.tx != nil {
+		return r.tx
+	}
+
+	return r.db.database
+}
+
+// RefreshDatabase downloads the catalog into the database. If a previous
+// refresh was interrupted, it resumes from that refresh's checkpoint instead
+// of starting over, keeping that refresh's incremental setting.
+//
+// Cancelling ctx stops the refresh between requests. Everything committed up
+// to that point is kept, so the next refresh resumes from there, unless
+// opts.AllOrNothing is set, in which case the whole refresh is rolled back.
+func (db Database) RefreshDatabase(ctx context.Context, opts RefreshOptions) error {
+	db.log.Printf("refreshing database (incremental: %v, all or nothing: %v)\n",
+		opts.Incremental, opts.AllOrNothing)
+
+	stmts, err := db.prepareStatements()
+	if err != nil {
+		err = fmt.Errorf("database.RefreshDatabase: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+	defer stmts.close()
+
+	r := &refresher{db: db, stmts: stmts, opts: opts}
+
+	if opts.AllOrNothing {
+		r.tx, err = db.database.Begin()
+		if err != nil {
+			err = fmt.Errorf("database.RefreshDatabase: %w", err)
+			db.log.Println(err)
+
+			return err
+		}
+
+		defer func() {
+			_ = r.tx.Rollback()
+		}()
+
+		r.txStmts = stmts.in(r.tx)
+	}
+
+	r.checkpoint, err = db.startRun(r.querier(), opts)
+	if err != nil {
+		err = fmt.Errorf("database.RefreshDatabase: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err = r.querier().QueryRow(queries["selectAnyCompleteRun"]).Scan(&r.alerts)
+	if err != nil {
+		err = fmt.Errorf("database.RefreshDatabase: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	// Without FTS5 the full-text index cannot be kept up to date, so a build
+	// with it must rebuild the index.
+	if !db.fts {
+		_, err = r.querier().Exec(queries["markSearchIndexStale"])
+		if err != nil {
+			err = fmt.Errorf("database.RefreshDatabase: %w", err)
+			db.log.Println(err)
+
+			return err
+		}
+	}
+
+	err = r.refresh(ctx)
+	if err != nil {
+		err = fmt.Errorf("database.RefreshDatabase: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	if r.tx != nil {
+		db.log.Println("committing refresh")
+
+		err = r.tx.Commit()
+		if err != nil {
+			err = fmt.Errorf("database.RefreshDatabase: %w", err)
+			db.log.Println(err)
+
+			return err
+		}
+	}
+
+	db.log.Println("done refreshing database")
+
+	return nil
+}
+
+// refresh carries out the run from its checkpoint: first every page of series
+// from the search engine is stored, then the details of each series flagged
+// needUpdate are downloaded and stored.
+func (r *refresher) refresh(ctx context.Context) error {
+	db := r.db
+
+	if r.checkpoint.phase == phaseSeries {
+		err := r.storeAllSeries(ctx)
+		if err != nil {
+			err = fmt.Errorf("database.refresh: %w", err)
+
+			return err
+		}
+	}
+
+	pending, err := db.getSeriesNeedingUpdate(r.querier(), r.checkpoint.lastSeriesUUID)
+	if err != nil {
+		err = fmt.Errorf("database.refresh: %w", err)
+
+		return err
+	}
+
+	err = r.updateAllSeries(ctx, pending)
+	if err != nil {
+		err = fmt.Errorf("database.refresh: %w", err)
+
+		return err
+	}
+
+	tx, stmts, err := r.begin()
+	if err != nil {
+		err = fmt.Errorf("database.refresh: %w", err)
+
+		return err
+	}
+
+	_, err = stmts["finishRun"].Exec(time.Now().Unix(), r.checkpoint.id)
+	if err != nil {
+		r.rollback(tx)
+
+		err = fmt.Errorf("database.refresh: %w", err)
+
+		return err
+	}
+
+	err = r.commit(tx)
+	if err != nil {
+		err = fmt.Errorf("database.refresh: %w", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// storeAllSeries pages through the search engine from the page after the
+// checkpoint, committing each page along with the checkpoint. Once all pages
+// are stored the run moves on to the details phase, flagging every series
+// for update first if this is a full refresh. Series not seen are marked
+// removed only if every page was fetched by this attempt.
+func (r *refresher) storeAllSeries(ctx context.Context) error {
+	db := r.db
+
+	db.log.Println("getting all series from DCUI API")
+
+	// The list is sorted by release, so series added to or removed from DCUI
+	// since the pages before the checkpoint were fetched shift the rest
+	// between pages, and a resumed run can miss some.
+	resumed := r.checkpoint.page >= startPage
+
+	for p, numPages := r.checkpoint.page+1, 0; numPages == 0 || p <= numPages; p++ {
+		page, err := db.getSeriesPage(ctx, p, r.checkpoint.pageSize)
+		if err != nil {
+			err = fmt.Errorf("database.storeAllSeries: %w", err)
+
+			return err
+		}
+
+		numPages = page.Info.ComicSeries.NumPages
+
+		err = r.storePage(p, page)
+		if err != nil {
+			err = fmt.Errorf("database.storeAllSeries: %w", err)
+
+			return err
+		}
+	}
+
+	tx, stmts, err := r.begin()
+	if err != nil {
+		err = fmt.Errorf("database.storeAllSeries: %w", err)
+
+		return err
+	}
+
+	if !r.checkpoint.incremental {
+		db.log.Println("flagging all series for update")
+
+		_, err = stmts["flagAllSeries"].Exec()
+		if err != nil {
+			r.rollback(tx)
+
+			err = fmt.Errorf("database.storeAllSeries: %w", err)
+
+			return err
+		}
+	}
+
+	if resumed {
+		db.log.Println("not recording removed series, as this run resumed partway through the series")
+	} else {
+		err = r.markRemovedSeries(stmts)
+		if err != nil {
+			r.rollback(tx)
+
+			err = fmt.Errorf("database.storeAllSeries: %w", err)
+
+			return err
+		}
+	}
+
+	_, err = stmts["checkpointPhase"].Exec(phaseDetails, r.checkpoint.id)
+	if err != nil {
+		r.rollback(tx)
+
+		err = fmt.Errorf("database.storeAllSeries: %w", err)
+
+		return err
+	}
+
+	err = r.commit(tx)
+	if err != nil {
+		err = fmt.Errorf("database.storeAllSeries: %w", err)
+
+		return err
+	}
+
+	r.checkpoint.phase = phaseDetails
+
+	db.log.Println("done getting all series")
+
+	return nil
+}
+
+// markRemovedSeries records and marks as removed every series on DCUI that
+// this run did not see, along with their issues.
+func (r *refresher) markRemovedSeries(stmts preparedStatements) error {
+	r.db.log.Println("recording removed series")
+
+	now := time.Now().Unix()
+
+	_, err := stmts["recordRemovedSeries"].Exec(r.checkpoint.id, now)
+	if err == nil {
+		_, err = stmts["markRemovedSeries"].Exec(r.checkpoint.id, now)
+	}
+
+	if err == nil {
+		_, err = stmts["markRemovedSeriesIssues"].Exec(now)
+	}
+
+	if err != nil {
+		return fmt.Errorf("database.markRemovedSeries: %w", err)
+	}
+
+	return nil
+}
+
+// storePage upserts every series in one page of search results and records
+// the page in the checkpoint.
+func (r *refresher) storePage(p int, page SearchResult) error {
+	db := r.db
+
+	tx, stmts, err := r.begin()
+	if err != nil {
+		err = fmt.Errorf("database.storePage: %w", err)
+
+		return err
+	}
+
+	t := page.Info.ComicSeries.TotalResultCount
+	for j, series := range page.Records.ComicSeries {
+		c := (p-startPage)*r.checkpoint.pageSize + j + 1
+
+		db.log.Printf("inserting %v/%v\n", c, t)
+
+		err = db.insertSeries(stmts, r.checkpoint.id, series)
+		if err != nil {
+			r.rollback(tx)
+
+			err = fmt.Errorf("database.storePage: %w", err)
+
+			return err
+		}
+	}
+
+	_, err = stmts["checkpointPage"].Exec(p, r.checkpoint.id)
+	if err != nil {
+		r.rollback(tx)
+
+		err = fmt.Errorf("database.storePage: %w", err)
+
+		return err
+	}
+
+	err = r.commit(tx)
+	if err != nil {
+		err = fmt.Errorf("database.storePage: %w", err)
+
+		return err
+	}
+
+	r.checkpoint.page = p
+
+	return nil
+}
+
+// fetchResult is the outcome of fetching the details of pending[index].
+type fetchResult struct {
+	index  int
+	update seriesUpdate
+	err    error
+}
+
+// updateAllSeries downloads the details of the pending series using a pool of
+// opts.Workers workers, sharing the API client's rate limit. Every download is
+// handed back to this goroutine, which is the only one writing to the
+// database. If anything fails, downloads still in progress are cancelled and
+// the first error is returned.
+func (r *refresher) updateAllSeries(ctx context.Context, pending []SearchResultRecordsComicseries) error {
+	db := r.db
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int)
+	results := make(chan fetchResult)
+
+	var workers sync.WaitGroup
+
+	for range max(r.opts.Workers, 1) {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			for i := range indexes {
+				update, err := db.fetchSeriesUpdate(ctx, pending[i])
+				results <- fetchResult{index: i, update: update, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(indexes)
+
+		for i := range pending {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	// Series finish out of order, so the checkpoint only advances past a
+	// series once it and every series before it have been handled.
+	handled := make([]bool, len(pending))
+	checkpoint := -1
+	stored := 0
+
+	var firstErr error
+
+	for result := range results {
+		if firstErr != nil {
+			continue
+		}
+
+		series := pending[result.index]
+		handled[result.index] = true
+
+		for checkpoint+1 < len(handled) && handled[checkpoint+1] {
+			checkpoint++
+		}
+
+		if result.err != nil {
+			if errors.Is(result.err, apiResponseError{}) {
+				db.log.Printf("skipping %v %v\n", series.UUID, series.Title)
+
+				continue
+			}
+
+			firstErr = fmt.Errorf("database.updateAllSeries: %w", result.err)
+
+			cancel()
+
+			continue
+		}
+
+		stored++
+
+		db.log.Printf("updating %v/%v\n", stored, len(pending))
+
+		checkpointUUID := r.checkpoint.lastSeriesUUID
+		if checkpoint >= 0 {
+			checkpointUUID = pending[checkpoint].UUID
+		}
+
+		err := r.storeSeriesUpdate(result.update, checkpointUUID)
+		if err != nil {
+			firstErr = fmt.Errorf("database.updateAllSeries: %w", err)
+
+			cancel()
+		}
+	}
+
+	return firstErr
+}
+
+// seriesUpdate is everything downloaded for a series flagged needUpdate.
+type seriesUpdate struct {
+	series      SearchResultRecordsComicseries
+	description string
+	books       []BookDetailsValues
+}
+
+// fetchSeriesUpdate downloads the description and issues for a single series.
+func (db Database) fetchSeriesUpdate(ctx context.Context, series SearchResultRecordsComicseries) (seriesUpdate, error) {
+	db.log.Printf("fetching series %v\n", series.UUID)
+
+	update := seriesUpdate{series: series}
+
+	description, err := db.getSeriesDescription(ctx, series.UUID)
+	if err != nil {
+		err = fmt.Errorf("database.fetchSeriesUpdate: %w", err)
+		db.log.Println(err)
+
+		return update, err
+	}
+
+	update.description = description
+
+	books, err := db.getSeriesBooks(ctx, series.UUID)
+	if err != nil {
+		err = fmt.Errorf("database.fetchSeriesUpdate: %w", err)
+		db.log.Println(err)
+
+		return update, err
+	}
+
+	update.books = books
+
+	return update, nil
+}
+
+// storeSeriesUpdate writes a downloaded series update in its own batch and
+// stamps the series as updated. checkpointUUID is recorded as the last
+// series stored; every pending series up to it has been handled.
+func (r *refresher) storeSeriesUpdate(update seriesUpdate, checkpointUUID string) error {
+	db := r.db
+	series := update.series
+
+	db.log.Printf("updating series %v\n", series.UUID)
+
+	tx, stmts, err := r.begin()
+	if err != nil {
+		err = fmt.Errorf("database.storeSeriesUpdate: %w", err)
+
+		return err
+	}
+
+	_, err = stmts["recordDescriptionChange"].Exec(r.checkpoint.id, update.description, time.Now().Unix(),
+		series.UUID, update.description)
+	if err != nil {
+		r.rollback(tx)
+
+		err = fmt.Errorf("database.storeSeriesUpdate: %w", err)
+
+		return err
+	}
+
+	_, err = stmts["updateSeriesDescription"].Exec(update.description, series.UUID)
+	if err != nil {
+		r.rollback(tx)
+
+		err = fmt.Errorf("database.storeSeriesUpdate: %w", err)
+
+		return err
+	}
+
+	err = db.indexDocument(stmts, KindSeries, series.UUID)
+	if err != nil {
+		r.rollback(tx)
+
+		err = fmt.Errorf("database.storeSeriesUpdate: %w", err)
+
+		return err
+	}
+
+	db.log.Printf("inserting %v issues for %v\n", len(update.books), series.UUID)
+
+	for _, book := range update.books {
+		err = db.insertIssue(stmts, r.checkpoint.id, series.UUID, book)
+		if err != nil {
+			r.rollback(tx)
+
+			err = fmt.Errorf("database.storeSeriesUpdate: %w", err)
+
+			return err
+		}
+
+		if !r.alerts {
+			continue
+		}
+
+		_, err = stmts["recordIssueAlert"].Exec(r.checkpoint.id, time.Now().Unix(), book.UUID)
+		if err != nil {
+			r.rollback(tx)
+
+			err = fmt.Errorf("database.storeSeriesUpdate: %w", err)
+
+			return err
+		}
+	}
+
+	// A series listed without any books is more likely a bad response than
+	// one emptied on DCUI, so its issues are kept.
+	if len(update.books) > 0 {
+		_, err = stmts["markRemovedIssues"].Exec(time.Now().Unix(), series.UUID, r.checkpoint.id)
+		if err != nil {
+			r.rollback(tx)
+
+			err = fmt.Errorf("database.storeSeriesUpdate: %w", err)
+
+			return err
+		}
+	}
+
+	_, err = stmts["setSeriesUpdated"].Exec(time.Now().Unix(), series.UUID)
+	if err != nil {
+		r.rollback(tx)
+
+		err = fmt.Errorf("database.storeSeriesUpdate: %w", err)
+
+		return err
+	}
+
+	_, err = stmts["checkpointSeries"].Exec(checkpointUUID, r.checkpoint.id)
+	if err != nil {
+		r.rollback(tx)
+
+		err = fmt.Errorf("database.storeSeriesUpdate: %w", err)
+
+		return err
+	}
+
+	err = r.commit(tx)
+	if err != nil {
+		err = fmt.Errorf("database.storeSeriesUpdate: %w", err)
+
+		return err
+	}
+
+	r.checkpoint.lastSeriesUUID = checkpointUUID
+
+	db.log.Println("series update complete")
+
+	return nil
+}
+
+// getSeriesNeedingUpdate returns the series flagged needUpdate, in uuid order,
+// that come after afterUUID.
+func (db Database) getSeriesNeedingUpdate(q querier, afterUUID string) ([]SearchResultRecordsComicseries, error) {
+	db.log.Println("getting series needing update")
+
+	rows, err := q.Query(queries["selectSeriesNeedingUpdate"], afterUUID)
+	if err != nil {
+		err = fmt.Errorf("database.getSeriesNeedingUpdate: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []SearchResultRecordsComicseries
+
+	for rows.Next() {
+		var series SearchResultRecordsComicseries
+
+		err = rows.Scan(&series.UUID, &series.Title)
+		if err != nil {
+			err = fmt.Errorf("database.getSeriesNeedingUpdate: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+
+		pending = append(pending, series)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("database.getSeriesNeedingUpdate: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	db.log.Printf("%v series need update\n", len(pending))
+
+	return pending, nil
+}
+
+// preparedStatements maps the names in statements, and in searchStatements if
+// the full-text index is available, to their prepared form.
+type preparedStatements map[string]*sql.Stmt
+
+func (db Database) prepareStatements() (preparedStatements, error) {
+	db.log.Println("preparing statements")
+
+	stmts := preparedStatements{}
+
+	sets := []map[string]string{statements}
+	if db.fts {
+		sets = append(sets, searchStatements)
+	}
+
+	for _, set := range sets {
+		for name, query := range set {
+			stmt, err := db.database.Prepare(query)
+			if err != nil {
+				stmts.close()
+
+				err = fmt.Errorf("database.prepareStatements: %v: %w", name, err)
+				db.log.Println(err)
+
+				return nil, err
+			}
+
+			stmts[name] = stmt
+		}
+	}
+
+	return stmts, nil
+}
+
+// in returns the statements bound to tx. They are closed automatically when
+// tx is committed or rolled back.
+func (stmts preparedStatements) in(tx *sql.Tx) preparedStatements {
+	txStmts := preparedStatements{}
+
+	for name, stmt := range stmts {
+		txStmts[name] = tx.Stmt(stmt)
+	}
+
+	return txStmts
+}
+
+func (stmts preparedStatements) close() {
+	for _, stmt := range stmts {
+		stmt.Close()
+	}
+}
+
+// insertSeries upserts a series seen by run runID, first recording whether it
+// is new, has been restored to DCUI or has gained books.
+func (db Database) insertSeries(stmts preparedStatements, runID int64, series SearchResultRecordsComicseries) error {
+	db.log.Printf("upserting series %v\n", series.UUID)
+
+	now := time.Now()
+
+	updateThreshold := now.AddDate(-1, 0, 0)
+	updateThresholdInt := updateThreshold.Unix()
+
+	url := fmt.Sprintf("https://www.dcuniverseinfinite.com/comics/series/%v/%v", series.Slug, series.UUID)
+
+	_, err := stmts["recordNewSeries"].Exec(runID, series.UUID, series.Title, now.Unix(), series.UUID)
+	if err != nil {
+		err = fmt.Errorf("database.insertSeries: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	_, err = stmts["recordRestoredSeries"].Exec(runID, now.Unix(), series.UUID)
+	if err != nil {
+		err = fmt.Errorf("database.insertSeries: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	_, err = stmts["recordBookCountIncrease"].Exec(runID, series.BooksCount, now.Unix(), series.UUID,
+		series.BooksCount)
+	if err != nil {
+		err = fmt.Errorf("database.insertSeries: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	_, err = stmts["upsertSeries"].Exec(
+		series.UUID,
+		series.Title,
+		series.BooksCount,
+		series.IssueCount,
+		series.VolumeCount,
+		series.OmnibusCount,
+		url,
+		runID,
+		updateThresholdInt)
+	if err != nil {
+		err = fmt.Errorf("database.insertSeries: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err = db.indexDocument(stmts, KindSeries, series.UUID)
+	if err != nil {
+		err = fmt.Errorf("database.insertSeries: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	db.log.Println("upserting series genres")
+
+	for _, g := range series.Genres {
+		_, err := stmts["upsertSeriesGenre"].Exec(series.UUID, g)
+		if err != nil {
+			err = fmt.Errorf("database.insertSeries: %w", err)
+			db.log.Println(err)
+
+			return err
+		}
+	}
+
+	db.log.Println("upserting series imprints")
+
+	for _, i := range series.Imprints {
+		_, err := stmts["upsertSeriesImprint"].Exec(series.UUID, i)
+		if err != nil {
+			err = fmt.Errorf("database.insertSeries: %w", err)
+			db.log.Println(err)
+
+			return err
+		}
+	}
+
+	db.log.Println("series upsert complete")
+
+	return nil
+}
+
+// insertIssue upserts an issue seen by run runID, restoring it if it had been
+// removed from DCUI.
+func (db Database) insertIssue(stmts preparedStatements, runID int64, seriesUUID string, book BookDetailsValues,
+) error {
+	db.log.Printf("upserting issue %v\n", book.UUID)
+
+	url := fmt.Sprintf("https://www.dcuniverseinfinite.com/comics/book/%v/%v/c", book.Slug, book.UUID)
+	plans := joinPlans(book.ExclusiveToPlans)
+
+	_, err := stmts["recordPlanChange"].Exec(runID, time.Now().Unix(), book.UUID, plans)
+	if err != nil {
+		err = fmt.Errorf("database.insertIssue: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	_, err = stmts["upsertIssue"].Exec(
+		book.UUID,
+		seriesUUID,
+		book.Title,
+		book.Description,
+		book.Publisher,
+		book.Imprint,
+		book.IssueNumber,
+		book.Pages,
+		parseDate(book.PublishDate),
+		url,
+		plans,
+		scheduledDate(book.PublishDate, time.Now()),
+		runID,
+		runID,
+		parseDate(book.PrintRelease))
+	if err != nil {
+		err = fmt.Errorf("database.insertIssue: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	err = db.indexDocument(stmts, KindIssue, book.UUID)
+	if err != nil {
+		err = fmt.Errorf("database.insertIssue: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	db.log.Println("upserting issue tags")
+
+	for _, t := range book.Tags {
+		categories := t.Categories
+		if len(categories) == 0 {
+			categories = []string{""}
+		}
+
+		for _, c := range categories {
+			_, err := stmts["upsertIssueTag"].Exec(book.UUID, c, t.Name)
+			if err != nil {
+				err = fmt.Errorf("database.insertIssue: %w", err)
+				db.log.Println(err)
+
+				return err
+			}
+		}
+	}
+
+	db.log.Println("upserting issue creators")
+
+	creators := map[string][]Creator{
+		"author":      book.Authors,
+		"coverArtist": book.CoverArtists,
+		"penciller":   book.Pencillers,
+		"inker":       book.Inkers,
+		"colorist":    book.Colorists,
+	}
+
+	for creatorType, cs := range creators {
+		for _, c := range cs {
+			_, err := stmts["upsertIssueCreator"].Exec(book.UUID, creatorType, c.Name, c.DisplayName)
+			if err == nil {
+				err = insertCreator(stmts, c)
+			}
+
+			if err != nil {
+				err = fmt.Errorf("database.insertIssue: %w", err)
+				db.log.Println(err)
+
+				return err
+			}
+		}
+	}
+
+	db.log.Println("issue upsert complete")
+
+	return nil
+}
+
+// insertCreator files a credited name under its canonical creator in the
+// creator index, creating one for a name unlike any seen before.
+func insertCreator(stmts preparedStatements, c Creator) error {
+	// A display name of nothing but spaces, periods and hyphens would file
+	// the name with every other such name, so the name stands in for it.
+	displayName := c.DisplayName
+	if strings.Trim(displayName, " .-") == "" {
+		displayName = c.Name
+	}
+
+	result, err := stmts["insertCreator"].Exec(displayName, c.Name, displayName)
+	if err != nil {
+		return fmt.Errorf("database.insertCreator: %w", err)
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("database.insertCreator: %w", err)
+	}
+
+	var id int64
+	if created > 0 {
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("database.insertCreator: %w", err)
+		}
+	}
+
+	_, err = stmts["insertCreatorAlias"].Exec(c.Name, id, displayName)
+	if err != nil {
+		return fmt.Errorf("database.insertCreator: %w", err)
+	}
+
+	return nil
+}
+
+// scheduledDate returns the day, as YYYY-MM-DD, that an issue with a DCUI API
+// publish date after now is scheduled to be added, or nil if it already has
+// been or its date is unknown.
+func scheduledDate(date string, now time.Time) any {
+	published := parseDate(date)
+	if published <= now.Unix() {
+		return nil
+	}
+
+	return time.Unix(published, 0).UTC().Format(time.DateOnly)
+}
+
+// parseDate converts a DCUI API date string to a Unix timestamp, returning 0
+// if the date is missing or in an unrecognized format.
+func parseDate(date string) int64 {
+	layouts := []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, date)
+		if err == nil {
+			return t.Unix()
+		}
+	}
+
+	return 0
+}
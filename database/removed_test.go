@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRemovedSeries(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Swamp Thing is pulled from DCUI and Batman loses its second issue.
+	fs.editFixture(t, "search", "page2", func(fixture map[string]any) {
+		fixture["records"].(map[string]any)["comicseries"] = []any{}
+	})
+	fs.editFixture(t, "books", batmanUUID, func(fixture map[string]any) {
+		fixture["values"] = fixture["values"].([]any)[:1]
+	})
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	series, total, err := db.QuerySeries(context.Background(), nil, QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 2 || len(series) != 2 || series[1].Title != "Harley Quinn's Greatest Hits" {
+		t.Errorf("series = %+v, want Swamp Thing left out", series)
+	}
+
+	series, _, err = db.QuerySeries(context.Background(), ByTitle("Swamp"), QueryOptions{IncludeRemoved: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(series) != 1 || series[0].DateRemoved.IsZero() {
+		t.Errorf("removed series = %+v, want Swamp Thing with a removal date", series)
+	}
+
+	issues, _, err := db.QueryIssues(context.Background(), ByTitle("Batman"), QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 1 || issues[0].Title != "Batman (2016-) #1" {
+		t.Errorf("Batman issues = %+v, want only #1", issues)
+	}
+
+	issues, _, err = db.QueryIssues(context.Background(), ByTitle("Batman"), QueryOptions{IncludeRemoved: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 2 || issues[0].DateRemoved.IsZero() == issues[1].DateRemoved.IsZero() {
+		t.Errorf("Batman issues including removed = %+v, want one of 2 removed", issues)
+	}
+
+	for _, includeRemoved := range []bool{false, true} {
+		series, err := db.SearchSeries(context.Background(), "swamp", includeRemoved)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		issues, err := db.IssuesPublished(context.Background(), time.Time{}, time.Time{}, includeRemoved)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hits, err := db.Search(context.Background(), "swamp", 0, includeRemoved)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		removedIssue := slices.ContainsFunc(issues, func(i Issue) bool { return i.UUID == batman10UUID })
+		removedHit := slices.ContainsFunc(hits, func(h SearchHit) bool { return h.Removed })
+
+		if (len(series) == 1) != includeRemoved || removedIssue != includeRemoved || removedHit != includeRemoved {
+			t.Errorf("including removed %v found %v Swamp Thing series, Batman #2 %v and a removed hit %v",
+				includeRemoved, len(series), removedIssue, removedHit)
+		}
+
+		// Only the removed Batman #2 is exclusive to Premium.
+		series, _, err = db.QuerySeries(context.Background(), ExclusiveTo("premium"),
+			QueryOptions{IncludeRemoved: includeRemoved})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (len(series) == 1) != includeRemoved {
+			t.Errorf("including removed %v found %+v series with a Premium issue", includeRemoved, series)
+		}
+	}
+
+	stats, err := db.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Series != 2 || stats.RemovedSeries != 1 {
+		t.Errorf("Stats = %+v, want 2 series and 1 removed", stats)
+	}
+
+	// Both come back.
+	fs.editFixture(t, "search", "page2", func(map[string]any) {})
+	fs.editFixture(t, "books", batmanUUID, func(map[string]any) {})
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := db.Changes(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "restoring refresh changes", changeStrings(changes), []string{
+		"restored:Swamp Thing::Swamp Thing",
+	})
+
+	series, _, err = db.QuerySeries(context.Background(), nil, QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, _, err = db.QueryIssues(context.Background(), ByTitle("Batman"), QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(series) != 3 || len(issues) != 2 {
+		t.Errorf("after restoring, %v series and %v Batman issues, want 3 and 2", len(series), len(issues))
+	}
+}
+
+func TestRemovedSeriesResume(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The next refresh stops after the first page, then Batman is pulled
+	// from DCUI, moving Swamp Thing to the first page, which the resumed
+	// refresh does not fetch again.
+	fs.breakFixture("search", "page2")
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err == nil {
+		t.Fatal("RefreshDatabase succeeded with malformed search response")
+	}
+
+	fs.repairFixture("search", "page2")
+
+	var swampThing any
+
+	fs.editFixture(t, "search", "page2", func(fixture map[string]any) {
+		records := fixture["records"].(map[string]any)
+		swampThing = records["comicseries"].([]any)[0]
+		records["comicseries"] = []any{}
+	})
+	fs.editFixture(t, "search", "page1", func(fixture map[string]any) {
+		records := fixture["records"].(map[string]any)
+		records["comicseries"] = []any{records["comicseries"].([]any)[1], swampThing}
+	})
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "removed after resuming",
+		queryStrings(t, db, "SELECT title FROM series WHERE dateRemoved <> 0"), nil)
+
+	// A refresh that fetches every page finds what was really removed.
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStrings(t, "removed after a whole refresh",
+		queryStrings(t, db, "SELECT title FROM series WHERE dateRemoved <> 0"), []string{"Batman (2016)"})
+}
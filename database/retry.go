@@ -0,0 +1,91 @@
+package database
+
+import (
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides whether and how long to wait before retrying a failed
+// API request. Transport errors are always retryable; responses are retried
+// only if their status code is listed in RetryStatuses or its class is listed
+// in RetryStatusClasses.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made, including the first.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. Each later wait is
+	// Multiplier times the one before, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomly shortens or lengthens each wait by up to this fraction
+	// of it, so that clients retrying together spread out.
+	Jitter float64
+	// MaxRetryAfter is the longest Retry-After header honored. Responses that
+	// ask for a longer wait are not retried.
+	MaxRetryAfter time.Duration
+	// RetryStatuses are individual status codes to retry, e.g. 429.
+	RetryStatuses []int
+	// RetryStatusClasses are classes of status codes to retry, given by their
+	// first digit, e.g. 5 for every 5xx status.
+	RetryStatusClasses []int
+}
+
+// DefaultRetryPolicy retries transport errors, 429 Too Many Requests and
+// server errors up to three times with exponential backoff.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:        4,
+		InitialBackoff:     2 * time.Second,
+		MaxBackoff:         defaultRetryDelay,
+		Multiplier:         2,
+		Jitter:             0.2,
+		MaxRetryAfter:      5 * time.Minute,
+		RetryStatuses:      []int{http.StatusTooManyRequests},
+		RetryStatusClasses: []int{5},
+	}
+}
+
+// retryableStatus reports whether a response with the given status code
+// should be retried.
+func (p RetryPolicy) retryableStatus(code int) bool {
+	return slices.Contains(p.RetryStatuses, code) || slices.Contains(p.RetryStatusClasses, code/100)
+}
+
+// backoff returns how long to wait after the given failed attempt, counting
+// from 1, before trying again.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 {
+		wait = math.Min(wait, float64(p.MaxBackoff))
+	}
+
+	if p.Jitter > 0 {
+		wait *= 1 + p.Jitter*(2*rand.Float64()-1) //nolint:gosec
+	}
+
+	return time.Duration(wait)
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an
+// HTTP date. ok is false if there is no usable header.
+func retryAfter(header string, now time.Time) (wait time.Duration, ok bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	seconds, err := strconv.Atoi(header)
+	if err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+
+	date, err := http.ParseTime(header)
+	if err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusServer responds with each of statuses in turn, then 200 OK.
+func statusServer(t *testing.T, retryAfter string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := int(requests.Add(1))
+		if n <= len(statuses) {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+
+			w.WriteHeader(statuses[n-1])
+
+			return
+		}
+
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func testRetryClient(srv *httptest.Server) *APIClient {
+	client := NewAPIClient()
+	client.Transport = srv.Client().Transport
+	client.Retry.InitialBackoff = time.Millisecond
+	client.Retry.MaxBackoff = 5 * time.Millisecond
+
+	return client
+}
+
+func TestRetryStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  int
+		wantReqs int32
+	}{
+		{"success", nil, 0, 1},
+		{"too many requests", []int{http.StatusTooManyRequests}, 0, 2},
+		{"server errors", []int{http.StatusBadGateway, http.StatusServiceUnavailable}, 0, 3},
+		{"not found", []int{http.StatusNotFound}, http.StatusNotFound, 1},
+		{"attempts exhausted", []int{500, 500, 500, 500, 500}, 500, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := statusServer(t, "", tt.statuses...)
+			client := testRetryClient(srv)
+
+			body, err := client.get(context.Background(), srv.URL)
+
+			var respErr apiResponseError
+
+			switch {
+			case tt.wantErr == 0 && err != nil:
+				t.Errorf("get error = %v", err)
+			case tt.wantErr == 0 && string(body) != "ok":
+				t.Errorf("get body = %q, want %q", body, "ok")
+			case tt.wantErr != 0 && !errors.As(err, &respErr):
+				t.Errorf("get error = %v, want apiResponseError", err)
+			case tt.wantErr != 0 && respErr.statusCode != tt.wantErr:
+				t.Errorf("get status = %v, want %v", respErr.statusCode, tt.wantErr)
+			}
+
+			if n := requests.Load(); n != tt.wantReqs {
+				t.Errorf("%v requests, want %v", n, tt.wantReqs)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv, requests := statusServer(t, "1", http.StatusTooManyRequests)
+	client := testRetryClient(srv)
+
+	start := time.Now()
+
+	_, err := client.post(context.Background(), srv.URL, []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least the 1s Retry-After", elapsed)
+	}
+
+	if n := requests.Load(); n != 2 {
+		t.Errorf("%v requests, want 2", n)
+	}
+
+	// A Retry-After longer than the policy allows is not waited for.
+	srv, requests = statusServer(t, "3600", http.StatusTooManyRequests)
+	client = testRetryClient(srv)
+
+	_, err = client.get(context.Background(), srv.URL)
+	if !errors.Is(err, apiResponseError{}) {
+		t.Errorf("get error = %v, want apiResponseError", err)
+	}
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%v requests, want 1", n)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second, true},
+		{"Wed, 01 May 2024 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := policy.backoff(i + 1); got != w {
+			t.Errorf("backoff(%v) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for range 100 {
+		if got := policy.backoff(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("backoff(1) with jitter = %v, want within 50%% of 1s", got)
+		}
+	}
+}
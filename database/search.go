@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SearchSeries returns the series whose titles contain title, ignoring case.
+// Titles that start with title come first, then the rest, each in title
+// order. An empty title matches every series. Series removed from DCUI are
+// only included if includeRemoved is true.
+func (db Database) SearchSeries(ctx context.Context, title string, includeRemoved bool) ([]Series, error) {
+	db.log.Printf("searching series for %q\n", title)
+
+	escaped := escapeLike(title)
+
+	rows, err := db.database.QueryContext(ctx, queries["searchSeriesTitle"], includeRemoved, "%"+escaped+"%",
+		escaped+"%")
+	if err != nil {
+		err = fmt.Errorf("database.SearchSeries: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	series, err := scanSeries(rows)
+	if err != nil {
+		err = fmt.Errorf("database.SearchSeries: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	db.log.Printf("%v series match %q\n", len(series), title)
+
+	return series, nil
+}
+
+// scanSeries reads every row of a query selecting the columns of Series in
+// order.
+func scanSeries(rows *sql.Rows) ([]Series, error) {
+	var series []Series
+
+	for rows.Next() {
+		var (
+			s                        Series
+			dateUpdated, dateRemoved int64
+		)
+
+		err := rows.Scan(&s.UUID, &s.Title, &s.Description, &s.BookCount, &s.IssueCount, &s.VolumeCount,
+			&s.OmnibusCount, &s.URL, &dateUpdated, &dateRemoved)
+		if err != nil {
+			return nil, fmt.Errorf("database.scanSeries: %w", err)
+		}
+
+		s.DateUpdated = unixTime(dateUpdated)
+		s.DateRemoved = unixTime(dateRemoved)
+
+		series = append(series, s)
+	}
+
+	err := rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("database.scanSeries: %w", err)
+	}
+
+	return series, nil
+}
+
+// unixTime converts a time stored as seconds since the epoch, where 0 means
+// none, to a time.Time, where the zero time means none.
+func unixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+}
+
+// escapeLike escapes the LIKE wildcards in s so that it matches literally in
+// a pattern using ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSearchSeries(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	err := db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		title string
+		want  []string
+	}{
+		{"", []string{"Batman (2016)", "Harley Quinn's Greatest Hits", "Swamp Thing"}},
+		{"S", []string{"Swamp Thing", "Harley Quinn's Greatest Hits"}},
+		{"quinn", []string{"Harley Quinn's Greatest Hits"}},
+		{"(2016)", []string{"Batman (2016)"}},
+		{"%", nil},
+		{"Bat_an", nil},
+	}
+
+	for _, tt := range tests {
+		series, err := db.SearchSeries(context.Background(), tt.title, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, s := range series {
+			got = append(got, s.Title)
+		}
+
+		assertStrings(t, "SearchSeries("+tt.title+")", got, tt.want)
+	}
+
+	series, err := db.SearchSeries(context.Background(), "batman", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(series) != 1 || series[0].UUID != batmanUUID || series[0].DateUpdated.IsZero() {
+		t.Errorf("SearchSeries(batman) = %+v, want updated series %v", series, batmanUUID)
+	}
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Stats summarizes what is in the database.
+type Stats struct {
+	Series   int
+	Issues   int
+	Creators int
+	Genres   int
+	Imprints int
+	// NeedUpdate is how many series will have their details downloaded by
+	// the next incremental refresh.
+	NeedUpdate int
+	// RemovedSeries is how many series have been removed from DCUI since
+	// they were first stored.
+	RemovedSeries int
+	// LastRefresh is when the last refresh finished, or the zero time if no
+	// refresh has finished.
+	LastRefresh time.Time
+	// RefreshInterrupted reports whether there is an interrupted refresh
+	// that the next refresh will resume.
+	RefreshInterrupted bool
+}
+
+func (db Database) Stats(ctx context.Context) (Stats, error) {
+	db.log.Println("getting database stats")
+
+	var (
+		stats       Stats
+		lastRefresh int64
+	)
+
+	err := db.database.QueryRowContext(ctx, queries["selectStats"]).Scan(
+		&stats.Series,
+		&stats.Issues,
+		&stats.Creators,
+		&stats.Genres,
+		&stats.Imprints,
+		&stats.NeedUpdate,
+		&stats.RemovedSeries,
+		&lastRefresh,
+		&stats.RefreshInterrupted)
+	if err != nil {
+		err = fmt.Errorf("database.Stats: %w", err)
+		db.log.Println(err)
+
+		return stats, err
+	}
+
+	if lastRefresh > 0 {
+		stats.LastRefresh = time.Unix(lastRefresh, 0)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+
+	stats, err := db.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats != (Stats{}) {
+		t.Errorf("Stats of empty database = %+v, want zero", stats)
+	}
+
+	err = db.RefreshDatabase(context.Background(), RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err = db.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Stats{
+		Series:      3,
+		Issues:      3,
+		Creators:    5,
+		Genres:      3,
+		Imprints:    3,
+		NeedUpdate:  1,
+		LastRefresh: stats.LastRefresh,
+	}
+
+	if stats != want {
+		t.Errorf("Stats = %+v, want %+v", stats, want)
+	}
+
+	if stats.LastRefresh.IsZero() {
+		t.Error("LastRefresh is zero after a refresh")
+	}
+}
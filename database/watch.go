@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of things that can be watched for new issues.
+const (
+	// WatchSeries watches a series, given by its UUID.
+	WatchSeries = "series"
+	// WatchCreator watches a creator, given by name or display name.
+	WatchCreator = "creator"
+	WatchGenre   = "genre"
+	WatchImprint = "imprint"
+)
+
+var (
+	errWatchKind  = errors.New("unknown watch kind")
+	errWatchValue = errors.New("nothing to watch")
+)
+
+// Watch is something on the watchlist. Refreshes alert about each new issue
+// that matches anything on it.
+type Watch struct {
+	Kind  string
+	Value string
+	// Title is the title of a watched series, and empty for other kinds.
+	Title string
+	Added time.Time
+}
+
+// Alert is a new issue that matched the watchlist when a refresh stored it.
+type Alert struct {
+	ID      int64
+	RunID   int64
+	Created time.Time
+	// Watch is what the issue matched. Its Added time is not set.
+	Watch Watch
+	Issue Issue
+}
+
+// Reason describes what an alert's issue matched, e.g. "creator Tom King".
+func (a Alert) Reason() string {
+	if a.Watch.Kind == WatchSeries && a.Watch.Title != "" {
+		return "series " + a.Watch.Title
+	}
+
+	return a.Watch.Kind + " " + a.Watch.Value
+}
+
+// validWatch checks kind and tidies value, returning an error if either is
+// unusable.
+func validWatch(kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case kind != WatchSeries && kind != WatchCreator && kind != WatchGenre && kind != WatchImprint:
+		return value, fmt.Errorf("%w: %q", errWatchKind, kind)
+	case value == "":
+		return value, fmt.Errorf("%w: empty %v", errWatchValue, kind)
+	default:
+		return value, nil
+	}
+}
+
+// AddWatch adds something to the watchlist. Names are matched ignoring case.
+// A watched series must be in the database.
+func (db Database) AddWatch(ctx context.Context, kind, value string) error {
+	db.log.Printf("watching %v %q\n", kind, value)
+
+	value, err := validWatch(kind, value)
+	if err == nil && kind == WatchSeries {
+		var exists bool
+
+		err = db.database.QueryRowContext(ctx, queries["selectSeriesExists"], value).Scan(&exists)
+		if err == nil && !exists {
+			err = fmt.Errorf("%w: %v", errUnknownSeries, value)
+		}
+	}
+
+	if err != nil {
+		err = fmt.Errorf("database.AddWatch: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	_, err = db.database.ExecContext(ctx, queries["insertWatch"], kind, value, time.Now().Unix())
+	if err != nil {
+		err = fmt.Errorf("database.AddWatch: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// RemoveWatch removes something from the watchlist. Its alerts are kept.
+func (db Database) RemoveWatch(ctx context.Context, kind, value string) error {
+	db.log.Printf("no longer watching %v %q\n", kind, value)
+
+	_, err := db.database.ExecContext(ctx, queries["deleteWatch"], kind, strings.TrimSpace(value))
+	if err != nil {
+		err = fmt.Errorf("database.RemoveWatch: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// Watching reports whether something is on the watchlist.
+func (db Database) Watching(ctx context.Context, kind, value string) (bool, error) {
+	var watching bool
+
+	err := db.database.QueryRowContext(ctx, queries["selectWatching"], kind, strings.TrimSpace(value)).Scan(
+		&watching)
+	if err != nil {
+		err = fmt.Errorf("database.Watching: %w", err)
+		db.log.Println(err)
+
+		return false, err
+	}
+
+	return watching, nil
+}
+
+// Watches returns the watchlist, grouped by kind.
+func (db Database) Watches(ctx context.Context) ([]Watch, error) {
+	rows, err := db.database.QueryContext(ctx, queries["selectWatches"])
+	if err != nil {
+		err = fmt.Errorf("database.Watches: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watches []Watch
+
+	for rows.Next() {
+		var (
+			w     Watch
+			added int64
+		)
+
+		err = rows.Scan(&w.Kind, &w.Value, &w.Title, &added)
+		if err != nil {
+			err = fmt.Errorf("database.Watches: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+
+		w.Added = unixTime(added)
+		watches = append(watches, w)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("database.Watches: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return watches, nil
+}
+
+// LastAlert returns the ID of the most recent alert, dismissed or not, or 0
+// if there are none. Passing it to Alerts after a refresh returns only the
+// alerts the refresh raised.
+func (db Database) LastAlert(ctx context.Context) (int64, error) {
+	var id int64
+
+	err := db.database.QueryRowContext(ctx, queries["selectLastAlert"]).Scan(&id)
+	if err != nil {
+		err = fmt.Errorf("database.LastAlert: %w", err)
+		db.log.Println(err)
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Alerts returns the alerts not yet dismissed with an ID above after, newest
+// first.
+func (db Database) Alerts(ctx context.Context, after int64) ([]Alert, error) {
+	rows, err := db.database.QueryContext(ctx, queries["selectPendingAlerts"], after)
+	if err != nil {
+		err = fmt.Errorf("database.Alerts: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []Alert
+
+	for rows.Next() {
+		var (
+			a       Alert
+			created int64
+		)
+
+		a.Issue, err = scanIssue(rows, &a.ID, &a.RunID, &a.Watch.Kind, &a.Watch.Value, &a.Watch.Title, &created)
+		if err != nil {
+			err = fmt.Errorf("database.Alerts: %w", err)
+			db.log.Println(err)
+
+			return nil, err
+		}
+
+		a.Created = unixTime(created)
+		alerts = append(alerts, a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = fmt.Errorf("database.Alerts: %w", err)
+		db.log.Println(err)
+
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// DismissAlert dismisses a single alert.
+func (db Database) DismissAlert(ctx context.Context, id int64) error {
+	db.log.Printf("dismissing alert %v\n", id)
+
+	_, err := db.database.ExecContext(ctx, queries["dismissAlert"], time.Now().Unix(), id)
+	if err != nil {
+		err = fmt.Errorf("database.DismissAlert: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// DismissAllAlerts dismisses every pending alert with an ID up to upTo, the
+// highest of those listed, so that alerts raised since by a refresh running
+// alongside are not dismissed unseen.
+func (db Database) DismissAllAlerts(ctx context.Context, upTo int64) error {
+	db.log.Printf("dismissing alerts up to %v\n", upTo)
+
+	_, err := db.database.ExecContext(ctx, queries["dismissAllAlerts"], time.Now().Unix(), upTo)
+	if err != nil {
+		err = fmt.Errorf("database.DismissAllAlerts: %w", err)
+		db.log.Println(err)
+
+		return err
+	}
+
+	return nil
+}
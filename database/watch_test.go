@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWatchAlerts(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+	ctx := context.Background()
+
+	err := db.AddWatch(ctx, WatchCreator, "tom king")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Series can only be watched once a refresh has stored them.
+	err = db.AddWatch(ctx, WatchSeries, batmanUUID)
+	if !errors.Is(err, errUnknownSeries) {
+		t.Errorf("AddWatch of a series not yet stored returned %v, want %v", err, errUnknownSeries)
+	}
+
+	err = db.AddWatch(ctx, "publisher", "DC")
+	if !errors.Is(err, errWatchKind) {
+		t.Errorf("AddWatch of a publisher returned %v, want %v", err, errWatchKind)
+	}
+
+	// Batman #2 is added after the first refresh, which alerts about
+	// nothing as every issue is new to it.
+	fs.editFixture(t, "books", batmanUUID, func(fixture map[string]any) {
+		fixture["values"] = fixture["values"].([]any)[:1]
+	})
+
+	err = db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last, err := db.LastAlert(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if last != 0 {
+		t.Errorf("first refresh raised alerts up to %v, want none", last)
+	}
+
+	err = db.AddWatch(ctx, WatchSeries, batmanUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs.editFixture(t, "books", batmanUUID, func(map[string]any) {})
+
+	err = db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alerts, err := db.Alerts(ctx, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(alerts) != 1 || alerts[0].Issue.UUID != batman10UUID || alerts[0].Reason() != "creator tom king" {
+		t.Fatalf("Alerts = %+v, want Batman #2 for Tom King", alerts)
+	}
+
+	watches, err := db.Watches(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(watches) != 2 || watches[1].Title != "Batman (2016)" {
+		t.Errorf("Watches = %+v, want the creator then the titled series", watches)
+	}
+
+	err = db.DismissAlert(ctx, alerts[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alerts, err = db.Alerts(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(alerts) != 0 {
+		t.Errorf("Alerts after dismissing = %+v, want none", alerts)
+	}
+}
+
+func TestDismissAllAlerts(t *testing.T) {
+	fs := newFixtureServer(t)
+	db := newTestDatabase(t, fs)
+	ctx := context.Background()
+
+	// Batman #2 and Harley's issue arrive in later refreshes.
+	fs.editFixture(t, "books", batmanUUID, func(fixture map[string]any) {
+		fixture["values"] = fixture["values"].([]any)[:1]
+	})
+	fs.editFixture(t, "books", harleyUUID, func(fixture map[string]any) {
+		fixture["values"] = []any{}
+	})
+
+	err := db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, uuid := range []string{batmanUUID, harleyUUID} {
+		err = db.AddWatch(ctx, WatchSeries, uuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs.editFixture(t, "books", batmanUUID, func(map[string]any) {})
+
+	err = db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shown, err := db.Alerts(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(shown) != 1 {
+		t.Fatalf("Alerts = %+v, want Batman #2", shown)
+	}
+
+	// Harley's issue is alerted about after the alerts were listed, so
+	// dismissing those leaves it pending.
+	fs.editFixture(t, "books", harleyUUID, func(map[string]any) {})
+
+	err = db.RefreshDatabase(ctx, RefreshOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.DismissAllAlerts(ctx, shown[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alerts, err := db.Alerts(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(alerts) != 1 || alerts[0].Issue.Series.UUID != harleyUUID {
+		t.Errorf("Alerts after dismissing those shown = %+v, want Harley's issue", alerts)
+	}
+}
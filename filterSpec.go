@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+// filterSpec is a filter as entered in the GUI or on the command line. Each
+// attribute may be given several values, any of which may match, and the
+// attributes given are combined with AND, or with OR if matchAny is set.
+type filterSpec struct {
+	genres   []string
+	imprints []string
+	creators []string
+	tags     []string
+	title    string
+	// minIssues and maxIssues are ignored if negative.
+	minIssues int
+	maxIssues int
+	// from and to are YYYY-MM-DD publication dates, ignored if empty.
+	from     string
+	to       string
+	matchAny bool
+	// plans are subscription plans, such as "ultra", that issues are
+	// exclusive to.
+	plans []string
+	// movedSince is a YYYY-MM-DD date since which issues moved to the base
+	// tier, ignored if empty.
+	movedSince string
+	// scheduled matches issues scheduled to be added to DCUI.
+	scheduled bool
+	// includeRemoved includes series and issues removed from DCUI. It is
+	// passed to the database in QueryOptions rather than the filter.
+	includeRemoved bool
+}
+
+func newFilterSpec() filterSpec {
+	return filterSpec{minIssues: -1, maxIssues: -1}
+}
+
+// filter builds the database filter for spec.
+func (spec filterSpec) filter() (database.Filter, error) {
+	var filters []database.Filter
+
+	anyOf := func(values []string, by func(string) database.Filter) {
+		var alternatives []database.Filter
+
+		for _, v := range values {
+			v = strings.TrimSpace(v)
+			if v != "" {
+				alternatives = append(alternatives, by(v))
+			}
+		}
+
+		if len(alternatives) > 0 {
+			filters = append(filters, database.Or(alternatives...))
+		}
+	}
+
+	anyOf(spec.genres, database.ByGenre)
+	anyOf(spec.imprints, database.ByImprint)
+	anyOf(spec.creators, database.ByCreator)
+	anyOf(spec.tags, database.ByTag)
+	anyOf([]string{spec.title}, database.ByTitle)
+	anyOf(spec.plans, database.ExclusiveTo)
+
+	if spec.minIssues >= 0 {
+		filters = append(filters, database.MinIssues(spec.minIssues))
+	}
+
+	if spec.maxIssues >= 0 {
+		filters = append(filters, database.MaxIssues(spec.maxIssues))
+	}
+
+	if spec.from != "" || spec.to != "" {
+		start, end, err := dateRange(spec.from, spec.to)
+		if err != nil {
+			return nil, fmt.Errorf("filterSpec.filter: %w", err)
+		}
+
+		filters = append(filters, database.PublishedBetween(start, end))
+	}
+
+	if spec.movedSince != "" {
+		since, _, err := dateRange(spec.movedSince, "")
+		if err != nil {
+			return nil, fmt.Errorf("filterSpec.filter: moved since: %w", err)
+		}
+
+		filters = append(filters, database.MovedToBaseTier(since, time.Time{}))
+	}
+
+	if spec.scheduled {
+		filters = append(filters, database.ScheduledToBeAdded(time.Now()))
+	}
+
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	if spec.matchAny {
+		return database.Or(filters...), nil
+	}
+
+	return database.And(filters...), nil
+}
+
+// monthStart is the first day of the month of now as a YYYY-MM-DD date.
+func monthStart(now time.Time) string {
+	return now.UTC().Format("2006-01") + "-01"
+}
+
+// splitList splits a comma-separated list entered by the user.
+func splitList(list string) []string {
+	var values []string
+
+	for _, v := range strings.Split(list, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// listFlag is a flag that may be repeated, or given a comma-separated list,
+// to collect several values.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, splitList(value)...)
+
+	return nil
+}
+
+// dateRange parses the YYYY-MM-DD dates from and to into the start of from
+// and the end of to, leaving either zero if it is empty. Publication dates
+// are stored as UTC days, so the dates are taken to be in UTC.
+func dateRange(from, to string) (time.Time, time.Time, error) {
+	var start, end time.Time
+
+	if from != "" {
+		d, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return start, end, fmt.Errorf("from date: %w", err)
+		}
+
+		start = d
+	}
+
+	if to != "" {
+		d, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return start, end, fmt.Errorf("to date: %w", err)
+		}
+
+		end = d.AddDate(0, 0, 1)
+	}
+
+	return start, end, nil
+}
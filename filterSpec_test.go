@@ -0,0 +1,82 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDateRange(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	for _, test := range []struct {
+		from, to   string
+		start, end time.Time
+	}{
+		{"", "", time.Time{}, time.Time{}},
+		{"2024-03-01", "", day(2024, time.March, 1), time.Time{}},
+		// The end is exclusive, so the to date is included in full.
+		{"", "2024-03-31", time.Time{}, day(2024, time.April, 1)},
+		{"2024-03-01", "2024-03-01", day(2024, time.March, 1), day(2024, time.March, 2)},
+		{"2023-12-01", "2023-12-31", day(2023, time.December, 1), day(2024, time.January, 1)},
+		{"2024-02-28", "2024-02-28", day(2024, time.February, 28), day(2024, time.February, 29)},
+	} {
+		start, end, err := dateRange(test.from, test.to)
+		if err != nil {
+			t.Fatalf("dateRange(%q, %q): %v", test.from, test.to, err)
+		}
+
+		if !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("dateRange(%q, %q) = %v, %v, want %v, %v", test.from, test.to, start, end, test.start, test.end)
+		}
+
+		// Publication dates are UTC days, whatever the local time zone.
+		if start.Location() != time.UTC || end.Location() != time.UTC {
+			t.Errorf("dateRange(%q, %q) = %v, %v, want UTC", test.from, test.to, start, end)
+		}
+	}
+
+	for _, test := range [][2]string{{"2024-13-01", ""}, {"", "31/03/2024"}, {"yesterday", "2024-03-31"}} {
+		_, _, err := dateRange(test[0], test[1])
+		if err == nil {
+			t.Errorf("dateRange(%q, %q) returned no error", test[0], test[1])
+		}
+	}
+}
+
+func TestFilterSpec(t *testing.T) {
+	spec := newFilterSpec()
+
+	f, err := spec.filter()
+	if err != nil || f != nil {
+		t.Errorf("empty filterSpec.filter() = %v, %v, want nil", f, err)
+	}
+
+	for _, spec := range []filterSpec{
+		{minIssues: -1, maxIssues: -1, from: "2024-02-30"},
+		{minIssues: -1, maxIssues: -1, to: "March"},
+		{minIssues: -1, maxIssues: -1, movedSince: "2024-3-1"},
+	} {
+		_, err := spec.filter()
+		if err == nil {
+			t.Errorf("filterSpec{%+v}.filter() returned no error for a bad date", spec)
+		}
+	}
+
+	spec.title = " batman "
+	spec.genres = []string{"", " "}
+
+	f, err = spec.filter()
+	if err != nil || f == nil {
+		t.Errorf("filterSpec.filter() = %v, %v, want a title filter", f, err)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	got := splitList(" Tom King, ,Mitch Gerads,")
+	if want := []string{"Tom King", "Mitch Gerads"}; !slices.Equal(got, want) {
+		t.Errorf("splitList = %q, want %q", got, want)
+	}
+}
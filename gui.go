@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+// runGUI shows the main window until it is closed or ctx is cancelled.
+func runGUI(ctx context.Context, stop context.CancelFunc, dbase database.Database) {
+	myApp := app.New()
+	myWindow := myApp.NewWindow("DCUI Scraper")
+
+	// Ctrl-C or SIGTERM cancels any running update and closes the window.
+	go func() {
+		<-ctx.Done()
+		myApp.Quit()
+	}()
+
+	var (
+		cancelRefresh context.CancelFunc
+		refreshing    sync.WaitGroup
+	)
+
+	updateActivity := widget.NewActivity()
+	updateActivity.Hide()
+
+	cancelButton := widget.NewButton("Cancel Update", func() {
+		mainLog.Println("cancelling update")
+		cancelRefresh()
+	})
+	cancelButton.Hide()
+
+	var updateButton, fullUpdateButton *widget.Button
+
+	// refresh updates the database in the background, only fetching the
+	// details of new or changed series if incremental is true, and reports
+	// any failure in a dialog.
+	var refresh func(incremental bool)
+	refresh = func(incremental bool) {
+		mainLog.Printf("updating DCUI database, incremental %v\n", incremental)
+
+		var refreshCtx context.Context
+		refreshCtx, cancelRefresh = context.WithCancel(ctx)
+
+		updateButton.Disable()
+		fullUpdateButton.Disable()
+		cancelButton.Show()
+		updateActivity.Show()
+		updateActivity.Start()
+
+		refreshing.Add(1)
+
+		go func() {
+			defer refreshing.Done()
+			defer cancelRefresh()
+
+			lastAlert, err := dbase.LastAlert(refreshCtx)
+			if err == nil {
+				err = dbase.RefreshDatabase(refreshCtx,
+					database.RefreshOptions{Incremental: incremental, Workers: refreshWorkers})
+			}
+
+			updateActivity.Stop()
+			updateActivity.Hide()
+			cancelButton.Hide()
+			updateButton.Enable()
+			fullUpdateButton.Enable()
+
+			switch {
+			case errors.Is(err, context.Canceled):
+				mainLog.Println("update cancelled")
+			case err != nil && incremental:
+				mainLog.Println(err)
+				dialog.ShowConfirm("Update Failed",
+					fmt.Sprintf("The update failed: %v\n\nTry a full update instead?", err), func(ok bool) {
+						if ok {
+							refresh(false)
+						}
+					}, myWindow)
+			case err != nil:
+				mainLog.Println(err)
+				dialog.ShowError(fmt.Errorf("the full update failed: %w", err), myWindow)
+			default:
+				mainLog.Println("update complete")
+				notifyAlerts(ctx, myApp, dbase, lastAlert)
+			}
+		}()
+	}
+
+	updateButton = widget.NewButton("Update DCUI Database", func() { refresh(true) })
+	fullUpdateButton = widget.NewButton("Full Update", func() {
+		dialog.ShowConfirm("Full Update", "Download the details of every series? This takes much longer than an "+
+			"update, but also finds the series and issues removed from DCUI.", func(ok bool) {
+			if ok {
+				refresh(false)
+			}
+		}, myWindow)
+	})
+
+	browse := newBrowser(ctx, dbase, myWindow)
+
+	filterText := canvas.NewText("Filters", color.White)
+	titleFilterButton := widget.NewButton("Title", browse.titleFilter)
+	dateFilterButton := widget.NewButton("Date Range", browse.dateFilter)
+	searchButton := widget.NewButton("Full Text", browse.textSearch)
+	moreFiltersButton := widget.NewButton("More Filters", browse.attributeFilter)
+	creatorsButton := widget.NewButton("Creators", browse.creatorIndex)
+
+	readingText := canvas.NewText("Reading", color.White)
+	continueButton := widget.NewButton("Continue Reading", browse.continueReading)
+	unreadButton := widget.NewButton("Unread in Followed", browse.unreadFollowed)
+	ordersButton := widget.NewButton("Reading Orders", browse.readingOrders)
+	alertsButton := widget.NewButton("Alerts", browse.alerts)
+
+	leftPane := container.New(layout.NewVBoxLayout(), updateButton, fullUpdateButton, cancelButton, updateActivity,
+		filterText, titleFilterButton, dateFilterButton, searchButton, moreFiltersButton, creatorsButton,
+		readingText, continueButton, unreadButton, ordersButton, alertsButton)
+	centerPane := container.NewBorder(canvas.NewText("Filter Options:", color.White), nil, nil, nil,
+		container.NewVScroll(browse.options))
+	rightPane := container.NewBorder(canvas.NewText("Filter Output:", color.White), nil, nil, nil,
+		browse.output)
+	rightSide := container.NewHSplit(centerPane, rightPane)
+	rightSide.Offset = 0.25
+
+	myWindow.SetContent(container.NewBorder(nil, nil, container.NewHBox(leftPane, widget.NewSeparator()), nil,
+		rightSide))
+	myWindow.Resize(fyne.NewSize(1280, 720))
+
+	myWindow.ShowAndRun()
+
+	// Stop any update still running so it is not cut off mid-write when the
+	// database is closed.
+	stop()
+	refreshing.Wait()
+}
+
+func buildContent() {
+	// TODO: Move UI building here, maybe?
+}
+
+func initError(err string) {
+	myApp := app.New()
+	myWindow := myApp.NewWindow("ERROR")
+	myWindow.SetContent(canvas.NewText(err, color.White))
+	myWindow.ShowAndRun()
+}
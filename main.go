@@ -1,123 +1,112 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"image/color"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/canvas"
-	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/layout"
-	"fyne.io/fyne/v2/widget"
 	"github.com/davidw1457/dcui-scraper/database"
 )
 
-const userRWX = 0o700
+const (
+	userRWX        = 0o700
+	refreshWorkers = 4
+	logFileName    = "dcui-scraper.log"
+)
 
 var mainLog *log.Logger //nolint:gochecknoglobals
 
+// mainLogPath is the file mainLog writes to, as configured.
+var mainLogPath string //nolint:gochecknoglobals
+
+// main runs the GUI when started with no command or with "gui", and the
+// command-line interface otherwise. Both share the database and log in
+// ~/.dcui unless configured otherwise.
 func main() {
-	userHome, err := os.UserHomeDir()
-	if err != nil {
-		initError(err.Error())
+	flags, args, err := parseConfigFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	} else if err != nil {
+		os.Exit(2) //nolint:mnd
+	}
+
+	gui := len(args) == 0 || args[0] == "gui"
+
+	// Setup errors are shown in a window for the GUI and on stderr for the
+	// command line.
+	fail := func(err string) {
+		if gui {
+			initError(err)
+		} else {
+			fmt.Fprintln(os.Stderr, "dcui-scraper:", err)
+		}
+
 		os.Exit(1)
 	}
 
-	sep := string(os.PathSeparator)
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		fail("unable to load config: " + err.Error())
+	}
+
+	cfg, err = cfg.Resolve()
+	if err != nil {
+		fail(err.Error())
+	}
 
-	logPath := userHome + sep + ".dcui" + sep + "logs"
+	logPath := cfg.LogDir
 
 	_, err = os.Stat(logPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			err = os.MkdirAll(logPath, userRWX)
 			if err != nil {
-				initError(err.Error())
-				os.Exit(1)
+				fail(err.Error())
 			}
 		} else {
-			initError(err.Error())
-			os.Exit(1)
+			fail(err.Error())
 		}
 	}
 
-	logFile, err := os.OpenFile(logPath+sep+"dcui-scraper.log",
-		os.O_WRONLY|os.O_CREATE|os.O_TRUNC, userRWX)
+	// The log is appended to rather than truncated so that a command-line run
+	// does not wipe out the log of a GUI session, or the other way round.
+	mainLogPath = filepath.Join(logPath, logFileName)
+
+	logFile, err := os.OpenFile(mainLogPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, userRWX)
 	if err != nil {
-		initError(err.Error())
-		os.Exit(1)
+		fail(err.Error())
 	}
 
 	mainLog = log.New(logFile, "main: ", log.LstdFlags)
 
 	mainLog.Println("opening backend database")
 
-	dbase, err := database.New()
+	dbase, err := database.New(cfg)
 	if err != nil {
-		mainLog.Fatalln("unable to open database")
+		mainLog.Println("unable to open database")
+		fail("unable to open database: " + err.Error())
 	}
 
-	defer dbase.Close()
-
-	myApp := app.New()
-	myWindow := myApp.NewWindow("DCUI Scraper")
+	// Ctrl-C or SIGTERM cancels whatever is running.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
-	var rightSide *fyne.Container
+	if gui {
+		runGUI(ctx, stop, dbase)
+		stop()
+		dbase.Close()
 
-	updateButton := widget.NewButton("Update DCUI Database", func() {
-		mainLog.Println("updating DCUI database")
-
-		holding := rightSide
-		rightSide = container.New(layout.NewCenterLayout(), widget.NewActivity())
-
-		rightSide.Refresh()
-
-		err := dbase.RefreshDatabase()
-		if err != nil {
-			// TODO: Update UI if there is an error
-			mainLog.Println(err)
-		}
-
-		rightSide = holding
-
-		rightSide.Refresh()
-		mainLog.Println("update comlete")
-	})
-	filterText := canvas.NewText("Filters", color.White)
-	titleFilterButton := widget.NewButton("Title", titleFilter)
-	dateFilterButton := widget.NewButton("Date Range", dateFilter)
-
-	leftPane := container.New(layout.NewVBoxLayout(), updateButton, filterText, titleFilterButton, dateFilterButton)
-	// TODO: Add a widget.NewList to hold filter contents
-	centerPane := container.New(layout.NewVBoxLayout(), canvas.NewText("Filter Options:", color.White))
-	// TODO: Add a widget.NewTable to hold filter output
-	rightPane := container.New(layout.NewVBoxLayout(), canvas.NewText("Filter Output:", color.White))
-	rightSide = container.New(layout.NewHBoxLayout(), centerPane, widget.NewSeparator(), rightPane)
-
-	myWindow.SetContent(container.New(layout.NewHBoxLayout(), leftPane, widget.NewSeparator(), rightSide,
-		layout.NewSpacer()))
-
-	myWindow.ShowAndRun()
-}
-
-func titleFilter() {
-	fmt.Println("titleFilterButton pressed")
-}
-
-func dateFilter() {
-	fmt.Println("dateFilter pressed")
-}
+		return
+	}
 
-func buildContent() {
-	// TODO: Move UI building here, maybe?
-}
+	code := runCLI(ctx, dbase, args)
 
-func initError(err string) {
-	myApp := app.New()
-	myWindow := myApp.NewWindow("ERROR")
-	myWindow.SetContent(canvas.NewText(err, color.White))
-	myWindow.ShowAndRun()
+	stop()
+	dbase.Close()
+	os.Exit(code)
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+// continueReading shows the next unread issue in each followed series.
+func (b *browser) continueReading() {
+	mainLog.Println("showing continue reading")
+
+	b.showIssueList("The next unread issue in each followed series.", b.dbase.ContinueReading)
+}
+
+// unreadFollowed shows every unread issue in the followed series.
+func (b *browser) unreadFollowed() {
+	mainLog.Println("showing unread in followed series")
+
+	b.showIssueList("Every unread issue in the followed series.", b.dbase.UnreadInFollowed)
+}
+
+// showIssueList shows the issues returned by list, described by about, with
+// a button to list them again after issues are marked read.
+func (b *browser) showIssueList(about string, list func(context.Context) ([]database.Issue, error)) {
+	count := widget.NewLabel("")
+
+	show := func() {
+		issues, err := list(b.ctx)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		count.SetText(fmt.Sprintf("%v issues", len(issues)))
+		b.showOutput(b.issueTable(issues))
+	}
+
+	description := widget.NewLabel(about + " Select an issue to mark it read. Follow a series by selecting it " +
+		"in a list of series.")
+	description.Wrapping = fyne.TextWrapWord
+
+	b.showOptions(description, widget.NewButton("Refresh", show), count)
+	show()
+}
+
+// seriesDetails shows a series' description with check boxes to follow it
+// and to be alerted about its new issues.
+func (b *browser) seriesDetails(s database.Series) {
+	following, err := b.dbase.Following(b.ctx, s.UUID)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	watching, err := b.dbase.Watching(b.ctx, database.WatchSeries, s.UUID)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	follow := widget.NewCheck("Follow", nil)
+	follow.SetChecked(following)
+	follow.OnChanged = func(checked bool) {
+		err := b.dbase.Follow(b.ctx, s.UUID, checked)
+		if err != nil {
+			b.showError(err)
+		}
+	}
+
+	watch := widget.NewCheck("Alert me about new issues", nil)
+	watch.SetChecked(watching)
+	watch.OnChanged = func(checked bool) {
+		var err error
+		if checked {
+			err = b.dbase.AddWatch(b.ctx, database.WatchSeries, s.UUID)
+		} else {
+			err = b.dbase.RemoveWatch(b.ctx, database.WatchSeries, s.UUID)
+		}
+
+		if err != nil {
+			b.showError(err)
+		}
+	}
+
+	description := widget.NewLabel(s.Description)
+	description.Wrapping = fyne.TextWrapWord
+
+	counts := widget.NewLabel(fmt.Sprintf("%v issues, %v volumes, %v omnibuses",
+		s.IssueCount, s.VolumeCount, s.OmnibusCount))
+
+	content := container.NewBorder(container.NewVBox(counts, follow, watch), widget.NewButton("Open on DCUI", func() {
+		openURL(s.URL)
+	}), nil, nil, container.NewVScroll(description))
+
+	details := dialog.NewCustom(seriesTitle(s), "Close", content, b.window)
+	details.Resize(fyne.NewSize(600, 400))
+	details.Show()
+}
+
+// ratings are the choices for an issue's rating, from none to
+// database.MaxRating stars.
+func ratings() []string {
+	choices := []string{"Unrated"}
+	for r := 1; r <= database.MaxRating; r++ {
+		choices = append(choices, strings.Repeat("★", r))
+	}
+
+	return choices
+}
+
+// issueDetails shows what has been recorded about reading an issue and
+// saves any changes made to it.
+func (b *browser) issueDetails(i database.Issue) {
+	reading, err := b.dbase.Reading(b.ctx, i.UUID)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	read := widget.NewCheck("Read", nil)
+	read.SetChecked(reading.Read)
+
+	dateRead := newDateEntry()
+	if !reading.DateRead.IsZero() {
+		dateRead.SetText(reading.DateRead.Format(time.DateOnly))
+	}
+
+	read.OnChanged = func(checked bool) {
+		if checked && dateRead.Text == "" {
+			dateRead.SetText(time.Now().Format(time.DateOnly))
+		}
+	}
+
+	rating := widget.NewSelect(ratings(), nil)
+	rating.SetSelectedIndex(reading.Rating)
+
+	notes := widget.NewMultiLineEntry()
+	notes.Wrapping = fyne.TextWrapWord
+	notes.SetText(reading.Notes)
+	notes.SetMinRowsVisible(4) //nolint:mnd
+
+	save := func(ok bool) {
+		if !ok {
+			return
+		}
+
+		var date time.Time
+		if dateRead.Text != "" {
+			var err error
+
+			date, err = time.ParseInLocation(time.DateOnly, dateRead.Text, time.Local)
+			if err != nil {
+				b.showError(fmt.Errorf("date read: %w", err))
+
+				return
+			}
+		}
+
+		err := b.dbase.SetRead(b.ctx, i.UUID, read.Checked, date)
+		if err == nil {
+			err = b.dbase.SetRating(b.ctx, i.UUID, rating.SelectedIndex())
+		}
+
+		if err == nil {
+			err = b.dbase.SetNotes(b.ctx, i.UUID, notes.Text)
+		}
+
+		if err != nil {
+			b.showError(err)
+		}
+	}
+
+	form := dialog.NewForm(issueTitle(i), "Save", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("", read),
+		widget.NewFormItem("Date read", dateRead),
+		widget.NewFormItem("Rating", rating),
+		widget.NewFormItem("Notes", notes),
+	}, save, b.window)
+	form.Resize(fyne.NewSize(500, 360))
+	form.Show()
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+// issueChoices is the most issues listed when choosing one to add to a
+// reading order.
+const issueChoices = 50
+
+// readingOrders lists the reading orders in the options pane, with forms to
+// build one from a tag or create an empty one.
+func (b *browser) readingOrders() {
+	mainLog.Println("showing reading orders")
+
+	orders, err := b.dbase.ReadingOrders(b.ctx)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	groups, err := b.dbase.TagGroups(b.ctx)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	options := []fyne.CanvasObject{widget.NewLabel("Reading orders:")}
+
+	for _, o := range orders {
+		options = append(options, widget.NewButton(fmt.Sprintf("%v (%v issues)", o.Name, o.Issues), func() {
+			b.showReadingOrder(o.ID, o.Name)
+		}))
+	}
+
+	if len(orders) == 0 {
+		options = append(options, widget.NewLabel("There are no reading orders yet."))
+	}
+
+	tagNames := make([]string, len(groups))
+	for i, g := range groups {
+		tagNames[i] = fmt.Sprintf("%v (%v, %v issues)", g.Name, g.Category, g.Issues)
+	}
+
+	tag := widget.NewSelect(tagNames, nil)
+	tag.PlaceHolder = "Event or storyline"
+
+	build := widget.NewButton("Build", func() {
+		if tag.SelectedIndex() < 0 {
+			return
+		}
+
+		g := groups[tag.SelectedIndex()]
+
+		id, err := b.dbase.BuildReadingOrder(b.ctx, g.Category, g.Name)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		b.readingOrders()
+		b.showReadingOrder(id, g.Name)
+	})
+
+	name := widget.NewEntry()
+	name.SetPlaceHolder("Name")
+
+	create := widget.NewButton("Create", func() {
+		id, err := b.dbase.CreateReadingOrder(b.ctx, name.Text)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		b.readingOrders()
+		b.showReadingOrder(id, name.Text)
+	})
+
+	options = append(options,
+		widget.NewSeparator(),
+		widget.NewLabel("Build from a tag, in print release order:"), tag, build,
+		widget.NewSeparator(),
+		widget.NewLabel("Create an empty order:"), name, create)
+
+	b.showOptions(options...)
+	b.showOutput(widget.NewLabel("Select a reading order to see its issues."))
+}
+
+// showReadingOrder lists the issues of a reading order with buttons to
+// reorder, add and remove them, and to rename, delete and export the order.
+func (b *browser) showReadingOrder(id int64, name string) {
+	mainLog.Printf("showing reading order %v\n", id)
+
+	issues, err := b.dbase.ReadingOrderIssues(b.ctx, id)
+	if err != nil {
+		b.showError(err)
+
+		return
+	}
+
+	selected := -1
+
+	list := widget.NewList(
+		func() int {
+			return len(issues)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, item fyne.CanvasObject) {
+			label := item.(*widget.Label) //nolint:forcetypeassert
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.SetText(fmt.Sprintf("%v. %v", i+1, issueTitle(issues[i])))
+		})
+	list.OnSelected = func(i widget.ListItemID) {
+		selected = i
+	}
+
+	// set saves the issues in their new order and shows them, selecting the
+	// issue at position to, if there is one.
+	set := func(reordered []database.Issue, to int) {
+		uuids := make([]string, len(reordered))
+		for i, issue := range reordered {
+			uuids[i] = issue.UUID
+		}
+
+		err := b.dbase.SetReadingOrderIssues(b.ctx, id, uuids)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		issues = reordered
+		list.Refresh()
+
+		if to >= 0 && to < len(issues) {
+			list.Select(to)
+		} else {
+			list.UnselectAll()
+			selected = -1
+		}
+	}
+
+	move := func(by int) {
+		to := selected + by
+		if selected < 0 || to < 0 || to >= len(issues) {
+			return
+		}
+
+		reordered := slices.Clone(issues)
+		reordered[selected], reordered[to] = reordered[to], reordered[selected]
+		set(reordered, to)
+	}
+
+	remove := widget.NewButton("Remove", func() {
+		if selected < 0 {
+			return
+		}
+
+		set(slices.Delete(slices.Clone(issues), selected, selected+1), -1)
+	})
+
+	details := widget.NewButton("Details", func() {
+		if selected >= 0 {
+			b.issueDetails(issues[selected])
+		}
+	})
+
+	add := widget.NewButton("Add Issue...", func() {
+		b.chooseIssue(func(i database.Issue) {
+			set(append(slices.Clone(issues), i), len(issues))
+		})
+	})
+
+	rename := widget.NewButton("Rename...", func() {
+		entry := widget.NewEntry()
+		entry.SetText(name)
+
+		dialog.ShowForm("Rename Reading Order", "Rename", "Cancel",
+			[]*widget.FormItem{widget.NewFormItem("Name", entry)}, func(ok bool) {
+				if !ok {
+					return
+				}
+
+				err := b.dbase.RenameReadingOrder(b.ctx, id, entry.Text)
+				if err != nil {
+					b.showError(err)
+
+					return
+				}
+
+				b.readingOrders()
+				b.showReadingOrder(id, entry.Text)
+			}, b.window)
+	})
+
+	deleteOrder := widget.NewButton("Delete", func() {
+		dialog.ShowConfirm("Delete Reading Order", fmt.Sprintf("Delete %v?", name), func(ok bool) {
+			if !ok {
+				return
+			}
+
+			err := b.dbase.DeleteReadingOrder(b.ctx, id)
+			if err != nil {
+				b.showError(err)
+
+				return
+			}
+
+			b.readingOrders()
+		}, b.window)
+	})
+
+	export := b.saveButton(name+".csv", func(w io.Writer, format database.ExportFormat) (int, error) {
+		return b.dbase.ExportReadingOrder(b.ctx, w, format, id)
+	})
+
+	buttons := container.NewHBox(
+		widget.NewButton("Up", func() { move(-1) }),
+		widget.NewButton("Down", func() { move(1) }),
+		details, add, remove, widget.NewSeparator(), rename, deleteOrder, export)
+
+	b.showOutput(container.NewBorder(widget.NewLabel(name), buttons, nil, nil, list))
+}
+
+// chooseIssue shows a dialog listing the issues whose titles contain the
+// text entered, and calls chosen with the one selected.
+func (b *browser) chooseIssue(chosen func(database.Issue)) {
+	var (
+		issues []database.Issue
+		choose dialog.Dialog
+	)
+
+	list := widget.NewList(
+		func() int {
+			return len(issues)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, item fyne.CanvasObject) {
+			label := item.(*widget.Label) //nolint:forcetypeassert
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.SetText(issueTitle(issues[i]))
+		})
+	list.OnSelected = func(i widget.ListItemID) {
+		choose.Hide()
+		chosen(issues[i])
+	}
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Issue title")
+	entry.OnChanged = func(title string) {
+		var err error
+
+		issues, _, err = b.dbase.QueryIssues(b.ctx, database.ByTitle(title), database.QueryOptions{Limit: issueChoices})
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	entry.OnChanged("")
+
+	choose = dialog.NewCustom("Add Issue", "Cancel", container.NewBorder(entry, nil, nil, nil, list), b.window)
+	choose.Resize(fyne.NewSize(600, 400))
+	choose.Show()
+	b.window.Canvas().Focus(entry)
+}
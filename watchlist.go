@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/davidw1457/dcui-scraper/database"
+)
+
+// alerts shows the new issues that matched the watchlist, with the watchlist
+// itself in the options pane. Series are watched from their details.
+func (b *browser) alerts() {
+	mainLog.Println("showing alerts")
+
+	count := widget.NewLabel("")
+	watches := container.NewVBox()
+
+	var showWatches, showAlerts func()
+
+	showWatches = func() {
+		list, err := b.dbase.Watches(b.ctx)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		watches.RemoveAll()
+
+		for _, w := range list {
+			name := w.Value
+			if w.Title != "" {
+				name = w.Title
+			}
+
+			remove := widget.NewButton("Remove", func() {
+				err := b.dbase.RemoveWatch(b.ctx, w.Kind, w.Value)
+				if err != nil {
+					b.showError(err)
+
+					return
+				}
+
+				showWatches()
+			})
+
+			watches.Add(container.NewBorder(nil, nil, nil, remove, widget.NewLabel(w.Kind+": "+name)))
+		}
+
+		if len(list) == 0 {
+			watches.Add(widget.NewLabel("Nothing is watched."))
+		}
+	}
+
+	showAlerts = func() {
+		alerts, err := b.dbase.Alerts(b.ctx, 0)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		count.SetText(fmt.Sprintf("%v alerts", len(alerts)))
+
+		// Only the alerts shown are dismissed, not any raised since by a
+		// refresh running alongside.
+		var upTo int64
+		for _, a := range alerts {
+			upTo = max(upTo, a.ID)
+		}
+
+		dismiss := widget.NewButton("Dismiss All", func() {
+			err := b.dbase.DismissAllAlerts(b.ctx, upTo)
+			if err != nil {
+				b.showError(err)
+
+				return
+			}
+
+			showAlerts()
+		})
+		if len(alerts) == 0 {
+			dismiss.Disable()
+		}
+
+		b.showOutput(container.NewBorder(nil, container.NewHBox(dismiss), nil, nil, b.alertTable(alerts)))
+	}
+
+	kind := widget.NewSelect([]string{database.WatchCreator, database.WatchGenre, database.WatchImprint}, nil)
+	kind.SetSelected(database.WatchCreator)
+
+	value := widget.NewEntry()
+	value.SetPlaceHolder("Name to watch for")
+
+	add := func() {
+		err := b.dbase.AddWatch(b.ctx, kind.Selected, value.Text)
+		if err != nil {
+			b.showError(err)
+
+			return
+		}
+
+		value.SetText("")
+		showWatches()
+	}
+	value.OnSubmitted = func(string) { add() }
+
+	b.showOptions(
+		widget.NewLabel("Watching:"), watches,
+		widget.NewSeparator(),
+		widget.NewForm(
+			widget.NewFormItem("Kind", kind),
+			widget.NewFormItem("Name", value)),
+		widget.NewButton("Watch", add),
+		widget.NewButton("Refresh Alerts", showAlerts),
+		count)
+	showWatches()
+	showAlerts()
+}
+
+// alertColumns are the headings of the columns in alertTable.
+var alertColumns = []string{"Raised", "Watching", "Series", "Issue", "Title", "URL"} //nolint:gochecknoglobals
+
+// alertTable lists alerts with their issues. Selecting a URL opens it in the
+// browser, and selecting anything else shows what has been recorded about
+// reading the issue.
+func (b *browser) alertTable(alerts []database.Alert) *widget.Table {
+	table := widget.NewTableWithHeaders(
+		func() (int, int) {
+			return len(alerts), len(alertColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, cell fyne.CanvasObject) {
+			a := alerts[id.Row]
+
+			var text string
+
+			switch id.Col {
+			case 0:
+				text = a.Created.Format(time.DateOnly)
+			case 1:
+				text = a.Reason()
+			case 2:
+				text = seriesTitle(a.Issue.Series)
+			case 3:
+				text = a.Issue.IssueNumber
+			case 4:
+				text = issueTitle(a.Issue)
+			case 5:
+				text = a.Issue.URL
+			}
+
+			label := cell.(*widget.Label) //nolint:forcetypeassert
+			label.Truncation = fyne.TextTruncateEllipsis
+			label.SetText(text)
+		})
+
+	table.ShowHeaderColumn = false
+	table.UpdateHeader = func(id widget.TableCellID, cell fyne.CanvasObject) {
+		cell.(*widget.Label).SetText(alertColumns[id.Col]) //nolint:forcetypeassert
+	}
+
+	table.SetColumnWidth(0, 100)
+	table.SetColumnWidth(1, 200)
+	table.SetColumnWidth(2, 240)
+	table.SetColumnWidth(3, 60)
+	table.SetColumnWidth(4, 260)
+	table.SetColumnWidth(5, 420)
+
+	table.OnSelected = func(id widget.TableCellID) {
+		if id.Col == 5 {
+			openURL(alerts[id.Row].Issue.URL)
+		} else {
+			b.issueDetails(alerts[id.Row].Issue)
+		}
+
+		table.Unselect(id)
+	}
+
+	return table
+}
+
+// notifyAlerts sends a desktop notification about the alerts raised since
+// the alert with ID last.
+func notifyAlerts(ctx context.Context, app fyne.App, dbase database.Database, last int64) {
+	alerts, err := dbase.Alerts(ctx, last)
+	if err != nil {
+		mainLog.Println(err)
+
+		return
+	}
+
+	switch len(alerts) {
+	case 0:
+		return
+	case 1:
+		a := alerts[0]
+		app.SendNotification(fyne.NewNotification("New on DCUI",
+			fmt.Sprintf("%v #%v (%v)", seriesTitle(a.Issue.Series), a.Issue.IssueNumber, a.Reason())))
+	default:
+		app.SendNotification(fyne.NewNotification("New on DCUI",
+			fmt.Sprintf("%v new issues match your watchlist", len(alerts))))
+	}
+}